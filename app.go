@@ -3,10 +3,14 @@ package ebrick
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 
+	"github.com/ebrickdev/ebrick/config"
+	"github.com/ebrickdev/ebrick/db"
 	"github.com/ebrickdev/ebrick/logger"
 	"github.com/ebrickdev/ebrick/module"
+	"github.com/ebrickdev/ebrick/tenancy"
 	"github.com/ebrickdev/ebrick/transport/grpc"
 	"github.com/ebrickdev/ebrick/transport/http"
 )
@@ -52,17 +56,33 @@ func (app *application) RegisterModules(ctx context.Context, modules ...module.M
 func (app *application) Start(ctx context.Context) error {
 	log := app.options.Logger
 
+	// Step 0: Validate the configuration and report the effective values before anything starts.
+	if err := app.checkConfig(log); err != nil {
+		return err
+	}
+
 	// Step 1: Register routes for web and gRPC services before starting any modules or servers.
 	if err := app.registerRoutesAndServices(log); err != nil {
 		return err
 	}
 
-	// Step 2: Start all modules
+	// Step 2: Apply the database migrations contributed by modules, then seed the database.
+	if err := app.migrate(ctx, log); err != nil {
+		return err
+	}
+	if err := app.migrateTenants(ctx, log); err != nil {
+		return err
+	}
+	if err := app.seed(ctx, log); err != nil {
+		return err
+	}
+
+	// Step 3: Start all modules
 	if err := app.mm.StartAllModules(ctx); err != nil {
 		return err
 	}
 
-	// Step 3: Start web server and gRPC server concurrently
+	// Step 4: Start web server and gRPC server concurrently
 	var wg sync.WaitGroup
 	var combinedErrMu sync.Mutex
 	var combinedErr error
@@ -86,11 +106,33 @@ func (app *application) Start(ctx context.Context) error {
 	}
 
 	wg.Wait()
+	for _, fn := range app.options.shutdown {
+		fn()
+	}
+	_ = log.Sync()
 	return combinedErr
 }
 
 // registerRoutesAndServices registers all routes for modules implementing http.Routable or grpc.ServiceRegistrar.
 func (app *application) registerRoutesAndServices(log logger.Logger) error {
+	if path := app.options.LogLevelPath; path != "" {
+		if levels, ok := logger.LevelsOf(app.options.Logger); ok && levels == app.options.LogLevels {
+			log.Info("Registering log level endpoint", logger.String("path", path))
+			mountAdmin(log, app.httpServer.Engine(), path, http.WrapH(levels), app.options.LogLevelMiddleware)
+		} else {
+			log.Warn("Log level endpoint not registered: the log levels do not drive the logger", logger.String("path", path))
+		}
+	}
+	if path := app.options.TenantPath; path != "" && app.options.Tenants != nil {
+		// The endpoint drops tenant databases; it is never left open.
+		if len(app.options.TenantMiddleware) == 0 {
+			log.Error("Tenant endpoint not registered: it requires middleware such as authentication", logger.String("path", path))
+		} else {
+			log.Info("Registering tenant endpoint", logger.String("path", path))
+			mountAdmin(log, app.httpServer.Engine(), path, http.WrapH(app.options.Tenants), app.options.TenantMiddleware)
+		}
+	}
+
 	for _, mod := range app.mm.GetModules() {
 		if svcReg, ok := mod.(grpc.ServiceRegistrar); ok {
 			log.Info("Registering gRPC service for module", logger.String("module", mod.Name()))
@@ -100,6 +142,132 @@ func (app *application) registerRoutesAndServices(log logger.Logger) error {
 	return nil
 }
 
+// mountAdmin registers an admin endpoint behind its middleware.
+func mountAdmin(log logger.Logger, engine *http.Engine, path string, handler http.HandlerFunc, middleware []http.HandlerFunc) {
+	if len(middleware) == 0 {
+		log.Warn("Admin endpoint registered without middleware; anyone reaching the server can use it", logger.String("path", path))
+	}
+	engine.Any(path, append(append([]http.HandlerFunc(nil), middleware...), handler)...)
+}
+
+// migrate applies the pending migrations of modules implementing db.MigrationSource.
+func (app *application) migrate(ctx context.Context, log logger.Logger) error {
+	if app.options.DB == nil {
+		return nil
+	}
+
+	migrator := db.NewMigrator(app.options.DB)
+	sources := 0
+	for _, mod := range app.sortedModules() {
+		src, ok := mod.(db.MigrationSource)
+		if !ok {
+			continue
+		}
+		migrations, err := src.Migrations()
+		if err == nil {
+			err = migrator.Register(mod.Id(), migrations...)
+		}
+		if err != nil {
+			log.Error("Failed to load migrations for module", logger.String("module", mod.Id()), logger.Error(err))
+			return err
+		}
+		sources++
+	}
+	if sources == 0 {
+		return nil
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		log.Error("Database migration failed", logger.Error(err))
+		return err
+	}
+	log.Info("Database migrations applied", logger.Int("count", len(applied)))
+	return nil
+}
+
+// migrateTenants registers the tenant migrations of modules implementing
+// tenancy.MigrationSource and applies them to the listed tenants.
+func (app *application) migrateTenants(ctx context.Context, log logger.Logger) error {
+	manager := app.options.Tenants
+	if manager == nil {
+		return nil
+	}
+
+	for _, mod := range app.sortedModules() {
+		src, ok := mod.(tenancy.MigrationSource)
+		if !ok {
+			continue
+		}
+		migrations, err := src.TenantMigrations()
+		if err == nil {
+			err = manager.Register(mod.Id(), migrations...)
+		}
+		if err != nil {
+			log.Error("Failed to load tenant migrations for module", logger.String("module", mod.Id()), logger.Error(err))
+			return err
+		}
+	}
+
+	if err := manager.MigrateAll(ctx); err != nil {
+		log.Error("Tenant migration failed", logger.Error(err))
+		return err
+	}
+	return nil
+}
+
+// seed runs the seeders of modules implementing db.SeederSource that match the environment.
+func (app *application) seed(ctx context.Context, log logger.Logger) error {
+	if app.options.DB == nil {
+		return nil
+	}
+
+	var seeders []db.Seeder
+	for _, mod := range app.sortedModules() {
+		if src, ok := mod.(db.SeederSource); ok {
+			seeders = append(seeders, src.Seeders()...)
+		}
+	}
+	if len(seeders) == 0 {
+		return nil
+	}
+	profile := config.GetAppConfig().Env
+	if _, err := db.RunSeeders(ctx, app.options.DB, profile, seeders...); err != nil {
+		log.Error("Database seeding failed", logger.String("profile", profile), logger.Error(err))
+		return err
+	}
+	return nil
+}
+
+// sortedModules returns the registered modules ordered by ID.
+func (app *application) sortedModules() []module.Module {
+	modules := app.mm.GetModules()
+	sorted := make([]module.Module, 0, len(modules))
+	for _, mod := range modules {
+		sorted = append(sorted, mod)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Id() < sorted[j].Id() })
+	return sorted
+}
+
+// checkConfig validates all registered configuration sections and logs the
+// effective configuration with secrets redacted.
+func (app *application) checkConfig(log logger.Logger) error {
+	sections := config.Sections()
+	if err := config.Validate(sections...); err != nil {
+		log.Error("Configuration validation failed", logger.Error(err))
+		return err
+	}
+
+	entries := config.Report(sections...)
+	fields := make([]logger.Field, 0, len(entries))
+	for _, e := range entries {
+		fields = append(fields, logger.Any(e.Key, e.Value))
+	}
+	log.Info("Effective configuration", fields...)
+	return nil
+}
+
 // combineError safely combines errors using a mutex.
 func combineError(mu *sync.Mutex, combinedErr *error, err error) {
 	if err != nil {
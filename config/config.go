@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"log"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -19,11 +21,64 @@ func init() {
 
 // Config represents the application configuration.
 type Config struct {
-	Env    string
-	Server ServerConfig
+	Env        string `default:"development"`
+	Server     ServerConfig
+	Log        LogConfig
+	Repository RepositoryConfig
+}
+
+// RepositoryConfig holds settings shared by the repositories of all modules.
+type RepositoryConfig struct {
+	// CursorKey signs pagination cursors. Set the same key on every instance so
+	// cursors stay valid across replicas and restarts.
+	CursorKey string `secret:"true"`
+}
+
+// LogConfig holds logger settings.
+type LogConfig struct {
+	// Level is a level spec such as "info,module.orders=debug". When empty the
+	// level is derived from Env.
+	Level string
+	// Format selects the output encoder: json, logfmt or console. When empty
+	// production and file output use json, and every other environment console.
+	Format string `validate:"omitempty,oneof=json logfmt console"`
+	// Async writes entries from a background goroutine through a bounded queue.
+	Async     bool
+	QueueSize int `default:"4096" validate:"min=1"`
+	Sampling  LogSamplingConfig
+	Redact    LogRedactConfig
+	File      LogFileConfig
+}
+
+// LogFileConfig holds settings for writing logs to a rotating file instead of stdout/stderr.
+type LogFileConfig struct {
+	// Path of the active log file; file logging is disabled when empty.
+	Path       string
+	MaxSizeMB  int           `validate:"min=0"`
+	Interval   time.Duration `validate:"min=0"`
+	MaxBackups int           `validate:"min=0"`
+	MaxAge     time.Duration `validate:"min=0"`
+	Compress   bool
+}
+
+// LogRedactConfig holds settings for removing sensitive data from log fields.
+type LogRedactConfig struct {
+	// Keys are added to the default sensitive key fragments (password, token, authorization, secret).
+	Keys []string
+	// Mode is "mask" or "hash"; hash yields correlatable pseudonyms keyed by HashKey.
+	Mode    string `default:"mask" validate:"oneof=mask hash"`
+	HashKey string `validate:"required_if=Mode hash" secret:"true"`
+}
+
+// LogSamplingConfig holds per-message log sampling settings.
+type LogSamplingConfig struct {
+	Enabled    bool
+	Initial    int           `default:"100" validate:"min=0"`
+	Thereafter int           `default:"100" validate:"min=0"`
+	Tick       time.Duration `default:"1s"`
 }
 type ServerConfig struct {
-	Port string
+	Port string `default:"8080" validate:"required,numeric"`
 }
 
 // LoadConfig loads the configuration from the specified paths.
@@ -55,7 +110,7 @@ func LoadConfig(configName string, configPaths []string, data any, defaults ...m
 	if err := viper.Unmarshal(data); err != nil {
 		return fmt.Errorf("error unmarshal config: %v", err)
 	}
-	return nil
+	return applyStructDefaults(data)
 }
 
 // GetConfig returns the application configuration.
@@ -90,5 +145,14 @@ func LoadConfigByKey(configName, key string, configPaths []string, data any, def
 	if err := viper.Sub(key).Unmarshal(data); err != nil {
 		return fmt.Errorf("error unmarshal config: %v", err)
 	}
-	return nil
+	return applyStructDefaults(data)
+}
+
+// applyStructDefaults applies `default` tags when data points to a struct; other targets are left untouched.
+func applyStructDefaults(data any) error {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return ApplyDefaults(data)
 }
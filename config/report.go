@@ -0,0 +1,78 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces secret values in the effective configuration report.
+const RedactedValue = "******"
+
+// SecretKeys lists key fragments whose values are always redacted in reports.
+// Fields can also be marked explicitly with the `secret:"true"` tag.
+var SecretKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "credential", "private", "dsn"}
+
+// ReportEntry is a single flattened key of the effective configuration.
+type ReportEntry struct {
+	Key   string
+	Value any
+}
+
+// Report flattens the given sections into sorted "section.key" entries with secrets redacted.
+func Report(sections ...Section) []ReportEntry {
+	var entries []ReportEntry
+	for _, s := range sections {
+		rv := reflect.ValueOf(s.Value)
+		for rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				break
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct {
+			entries = append(entries, ReportEntry{Key: s.Name, Value: s.Value})
+			continue
+		}
+		entries = flatten(entries, s.Name, rv, false)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+func flatten(entries []ReportEntry, prefix string, rv reflect.Value, secret bool) []ReportEntry {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := prefix + "." + keyName(sf)
+		fieldSecret := secret || sf.Tag.Get("secret") == "true" || isSecretKey(sf.Name)
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			entries = flatten(entries, key, fv, fieldSecret)
+			continue
+		}
+		value := fv.Interface()
+		if fieldSecret && !fv.IsZero() {
+			value = RedactedValue
+		}
+		entries = append(entries, ReportEntry{Key: key, Value: value})
+	}
+	return entries
+}
+
+func isSecretKey(name string) bool {
+	name = strings.ToLower(name)
+	for _, k := range SecretKeys {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Section is a named configuration block that takes part in startup
+// validation and in the effective configuration report.
+type Section struct {
+	Name  string
+	Value any // pointer to the loaded configuration struct
+}
+
+var (
+	sectionsMu sync.RWMutex
+	sections   []Section
+)
+
+// RegisterSection registers a configuration block to be validated and reported at startup.
+// Modules call it from Initialize with the struct they loaded via LoadConfigByKey.
+// Registering the same name twice replaces the previous value.
+func RegisterSection(name string, value any) {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	for i, s := range sections {
+		if s.Name == name {
+			sections[i].Value = value
+			return
+		}
+	}
+	sections = append(sections, Section{Name: name, Value: value})
+}
+
+// Sections returns the registered configuration sections in registration order.
+func Sections() []Section {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+	return append([]Section(nil), sections...)
+}
+
+// Problem describes a single invalid configuration value.
+type Problem struct {
+	Section string
+	Key     string
+	Rule    string
+	Value   any
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s failed '%s' (value: %v)", p.Section, p.Key, p.Rule, p.Value)
+}
+
+// ValidationError aggregates every problem found while validating the configuration.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d problems):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+// validatorInstance returns a validator that reports keys by their config names.
+func validatorInstance() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New()
+		validate.RegisterTagNameFunc(keyName)
+	})
+	return validate
+}
+
+// Validate applies `default` tags and then checks `validate` tags on every section.
+// All problems are collected and returned as a single *ValidationError.
+func Validate(sections ...Section) error {
+	var problems []Problem
+	for _, s := range sections {
+		if err := ApplyDefaults(s.Value); err != nil {
+			problems = append(problems, Problem{Section: s.Name, Key: "-", Rule: "default", Value: err})
+			continue
+		}
+		err := validatorInstance().Struct(s.Value)
+		if err == nil {
+			continue
+		}
+		fieldErrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			problems = append(problems, Problem{Section: s.Name, Key: "-", Rule: "struct", Value: err})
+			continue
+		}
+		for _, fe := range fieldErrs {
+			rule := fe.Tag()
+			if fe.Param() != "" {
+				rule += "=" + fe.Param()
+			}
+			value := fe.Value()
+			if isSecretPath(reflect.TypeOf(s.Value), trimRoot(s.Value, fe.StructNamespace())) && value != nil && !reflect.ValueOf(value).IsZero() {
+				value = RedactedValue
+			}
+			problems = append(problems, Problem{
+				Section: s.Name,
+				Key:     trimRoot(s.Value, fe.Namespace()),
+				Rule:    rule,
+				Value:   value,
+			})
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ApplyDefaults sets zero-valued fields of the struct pointed to by v from their `default` tag.
+func ApplyDefaults(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: expected pointer to struct, got %T", v)
+	}
+	return applyDefaults(rv.Elem())
+}
+
+func applyDefaults(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			if err := applyDefaults(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		def, ok := sf.Tag.Lookup("default")
+		if !ok || !fv.IsZero() {
+			continue
+		}
+		if err := setFromString(fv, def); err != nil {
+			return fmt.Errorf("config: invalid default for %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func setFromString(fv reflect.Value, s string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", fv.Type())
+		}
+		parts := strings.Split(s, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		fv.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported kind %s", fv.Kind())
+	}
+	return nil
+}
+
+// keyName returns the configuration key of a struct field, preferring the
+// mapstructure tag used by viper, then the yaml tag, then the lowercased name.
+func keyName(sf reflect.StructField) string {
+	for _, tag := range []string{"mapstructure", "yaml"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(sf.Name)
+}
+
+// trimRoot drops the struct type name from a validator namespace ("Config.grpc.address" -> "grpc.address").
+// Namespaces of anonymous structs have no type name to drop.
+func trimRoot(root any, ns string) string {
+	rt := reflect.TypeOf(root)
+	for rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt != nil && rt.Name() == "" {
+		return ns
+	}
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+// isSecretPath reports whether the field at the dotted Go field path of rt, or
+// one of the structs holding it, is redacted in reports.
+func isSecretPath(rt reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		name, _, _ = strings.Cut(name, "[")
+		for rt.Kind() == reflect.Pointer || rt.Kind() == reflect.Slice || rt.Kind() == reflect.Map {
+			rt = rt.Elem()
+		}
+		if rt.Kind() != reflect.Struct {
+			return false
+		}
+		sf, ok := rt.FieldByName(name)
+		if !ok {
+			return false
+		}
+		if sf.Tag.Get("secret") == "true" || isSecretKey(sf.Name) {
+			return true
+		}
+		rt = sf.Type
+	}
+	return false
+}
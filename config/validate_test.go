@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDatabase struct {
+	Host     string        `default:"localhost" validate:"required"`
+	Port     int           `default:"5432" validate:"min=1,max=65535"`
+	Password string        `validate:"required"`
+	Timeout  time.Duration `default:"5s"`
+}
+
+type testSection struct {
+	Name     string `mapstructure:"name" validate:"required"`
+	Address  string `yaml:"address" validate:"omitempty,hostname_port"`
+	Database testDatabase
+}
+
+func TestApplyDefaults(t *testing.T) {
+	cfg := &testSection{Database: testDatabase{Port: 6543}}
+
+	err := ApplyDefaults(cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, 6543, cfg.Database.Port)
+	assert.Equal(t, 5*time.Second, cfg.Database.Timeout)
+}
+
+func TestApplyDefaultsRejectsNonStruct(t *testing.T) {
+	var s string
+	assert.Error(t, ApplyDefaults(&s))
+	assert.Error(t, ApplyDefaults(testSection{}))
+}
+
+func TestValidateAggregatesProblems(t *testing.T) {
+	cfg := &testSection{Address: ":0"}
+	grpcCfg := &struct {
+		Port string `validate:"required,numeric"`
+	}{Port: "abc"}
+
+	err := Validate(Section{Name: "app", Value: cfg}, Section{Name: "grpc", Value: grpcCfg})
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 4)
+	assert.Equal(t, Problem{Section: "app", Key: "name", Rule: "required", Value: ""}, verr.Problems[0])
+	assert.Equal(t, "address", verr.Problems[1].Key)
+	assert.Equal(t, "hostname_port", verr.Problems[1].Rule)
+	assert.Equal(t, "database.password", verr.Problems[2].Key)
+	assert.Equal(t, "grpc", verr.Problems[3].Section)
+	assert.Contains(t, err.Error(), "4 problems")
+}
+
+func TestValidateValid(t *testing.T) {
+	cfg := &testSection{Name: "svc", Address: ":50051", Database: testDatabase{Password: "pw"}}
+	assert.NoError(t, Validate(Section{Name: "app", Value: cfg}))
+}
+
+func TestReportRedactsSecrets(t *testing.T) {
+	cfg := &struct {
+		Name  string
+		Token string
+		DB    struct {
+			URL string `secret:"true"`
+		} `mapstructure:"db"`
+		Empty string `secret:"true"`
+	}{Name: "svc", Token: "abc"}
+	cfg.DB.URL = "postgres://user:pw@host/db"
+
+	entries := Report(Section{Name: "app", Value: cfg})
+
+	assert.Equal(t, []ReportEntry{
+		{Key: "app.db.url", Value: RedactedValue},
+		{Key: "app.empty", Value: ""},
+		{Key: "app.name", Value: "svc"},
+		{Key: "app.token", Value: RedactedValue},
+	}, entries)
+}
+
+func TestRegisterSectionReplaces(t *testing.T) {
+	RegisterSection("test-section", 1)
+	RegisterSection("test-section", 2)
+
+	var found []Section
+	for _, s := range Sections() {
+		if s.Name == "test-section" {
+			found = append(found, s)
+		}
+	}
+	assert.Equal(t, []Section{{Name: "test-section", Value: 2}}, found)
+}
+
+func TestValidateRedactsSecrets(t *testing.T) {
+	cfg := &struct {
+		Password string `validate:"min=12"`
+		Redact   struct {
+			HashKey string `validate:"len=32" secret:"true"`
+		}
+		Name string `validate:"max=2"`
+	}{Password: "hunter2", Name: "visible"}
+	cfg.Redact.HashKey = "short-hash-key"
+
+	err := Validate(Section{Name: "app", Value: cfg})
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 3)
+	assert.Equal(t, RedactedValue, verr.Problems[0].Value)
+	assert.Equal(t, RedactedValue, verr.Problems[1].Value)
+	assert.Equal(t, "visible", verr.Problems[2].Value)
+	assert.NotContains(t, err.Error(), "hunter2")
+	assert.NotContains(t, err.Error(), "short-hash-key")
+}
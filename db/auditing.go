@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/ebrickdev/ebrick/security/auth"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultAuditActor is recorded when no principal is authenticated, e.g. in background jobs.
+const DefaultAuditActor = "system"
+
+// Names of the entity.Auditing fields filled by AuditingPlugin.
+const (
+	createdByField = "CreatedBy"
+	updatedByField = "UpdatedBy"
+)
+
+// AuditingPlugin is a GORM plugin that fills the CreatedBy and UpdatedBy fields
+// of entities embedding entity.Auditing with the ID of the auth.Principal in
+// the statement context. CreatedBy is only set when empty, so imported data
+// keeps its author.
+type AuditingPlugin struct {
+	// Fallback is recorded when the context has no principal; DefaultAuditActor when empty.
+	Fallback string
+}
+
+// Name implements gorm.Plugin.
+func (p *AuditingPlugin) Name() string {
+	return "ebrick:auditing"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *AuditingPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("ebrick:auditing_create", p.beforeCreate); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("ebrick:auditing_update", p.beforeUpdate)
+}
+
+// actor returns the ID of the principal in ctx or the fallback.
+func (p *AuditingPlugin) actor(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.GetID() != "" {
+		return principal.GetID()
+	}
+	if p.Fallback != "" {
+		return p.Fallback
+	}
+	return DefaultAuditActor
+}
+
+func (p *AuditingPlugin) beforeCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	createdBy := stmt.Schema.LookUpField(createdByField)
+	updatedBy := stmt.Schema.LookUpField(updatedByField)
+	if createdBy == nil && updatedBy == nil {
+		return
+	}
+
+	actor := p.actor(stmt.Context)
+	set := func(rv reflect.Value) {
+		if createdBy != nil {
+			if _, zero := createdBy.ValueOf(stmt.Context, rv); zero {
+				db.AddError(createdBy.Set(stmt.Context, rv, actor))
+			}
+		}
+		if updatedBy != nil {
+			db.AddError(updatedBy.Set(stmt.Context, rv, actor))
+		}
+	}
+	switch rv := stmt.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				set(elem)
+			}
+		}
+	case reflect.Struct:
+		set(rv)
+	}
+}
+
+func (p *AuditingPlugin) beforeUpdate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	field := stmt.Schema.LookUpField(updatedByField)
+	if field == nil {
+		return
+	}
+	stmt.SetColumn(field.DBName, p.actor(stmt.Context))
+	// Updates restricted by Select would otherwise skip the column.
+	if len(stmt.Selects) > 0 && !selected(stmt.Selects, field) {
+		stmt.Selects = append(stmt.Selects, field.DBName)
+	}
+}
+
+// selected reports whether field is among the selected columns.
+func selected(selects []string, field *schema.Field) bool {
+	for _, s := range selects {
+		if s == "*" || s == field.DBName || s == field.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/entity"
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type principal struct{ id string }
+
+func (p principal) GetID() string                     { return p.id }
+func (p principal) GetEmail() string                  { return "" }
+func (p principal) GetRoles() []string                { return nil }
+func (p principal) GetClaims() map[string]interface{} { return nil }
+
+type auditedItem struct {
+	ID   uint
+	Name string
+	entity.Auditing
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func TestAuditingPlugin(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.Use(&AuditingPlugin{}))
+	require.NoError(t, db.AutoMigrate(&auditedItem{}))
+
+	alice := auth.ContextWithPrincipal(context.Background(), principal{id: "alice"})
+	bob := auth.ContextWithPrincipal(context.Background(), principal{id: "bob"})
+
+	item := &auditedItem{Name: "a"}
+	require.NoError(t, db.WithContext(alice).Create(item).Error)
+	assert.Equal(t, "alice", item.CreatedBy)
+	assert.Equal(t, "alice", item.UpdatedBy)
+
+	item.Name = "b"
+	require.NoError(t, db.WithContext(bob).Save(item).Error)
+	require.NoError(t, db.WithContext(context.Background()).Model(item).Select("name").Updates(&auditedItem{Name: "c"}).Error)
+
+	var got auditedItem
+	require.NoError(t, db.First(&got, item.ID).Error)
+	assert.Equal(t, "c", got.Name)
+	assert.Equal(t, "alice", got.CreatedBy)
+	assert.Equal(t, DefaultAuditActor, got.UpdatedBy)
+
+	require.NoError(t, db.WithContext(bob).Model(&got).Update("name", "d").Error)
+	require.NoError(t, db.First(&got, item.ID).Error)
+	assert.Equal(t, "bob", got.UpdatedBy)
+}
+
+func TestAuditingPluginBatchAndFallback(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.Use(&AuditingPlugin{Fallback: "cron"}))
+	require.NoError(t, db.AutoMigrate(&auditedItem{}))
+
+	items := []*auditedItem{{Name: "a"}, {Name: "b", Auditing: entity.Auditing{CreatedBy: "import"}}}
+	require.NoError(t, db.Create(items).Error)
+
+	assert.Equal(t, "cron", items[0].CreatedBy)
+	assert.Equal(t, "import", items[1].CreatedBy)
+	assert.Equal(t, "cron", items[1].UpdatedBy)
+}
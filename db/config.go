@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ebrickdev/ebrick/config"
+)
+
+// Config is the database section of application.yaml, e.g.
+//
+//	database:
+//	  driver: postgres
+//	  dsn: host=localhost user=app dbname=app sslmode=disable
+//	  maxopenconns: 20
+//	  connmaxlifetime: 30m
+//	  slowthreshold: 500ms
+//	datasources:
+//	  replica:
+//	    driver: postgres
+//	    dsn: host=replica user=app dbname=app sslmode=disable
+//	  analytics:
+//	    driver: postgres
+//	    dsn: host=warehouse user=reports dbname=analytics sslmode=disable
+type Config struct {
+	// Database is the primary data source.
+	Database DataSourceConfig `yaml:"database"`
+	// DataSources holds further named data sources, e.g. replica and analytics.
+	DataSources map[string]DataSourceConfig `yaml:"datasources" validate:"dive"`
+}
+
+// DataSourceConfig holds the settings of a database connection.
+type DataSourceConfig struct {
+	// Driver is postgres, mysql or sqlite.
+	Driver string `default:"postgres" validate:"oneof=postgres mysql sqlite"`
+	// DSN is the driver specific data source name; no database is opened when empty.
+	DSN string
+	// MaxOpenConns limits the open connections; 0 means unlimited.
+	MaxOpenConns int `validate:"min=0"`
+	// MaxIdleConns limits the idle connections kept in the pool.
+	MaxIdleConns    int           `default:"2" validate:"min=0"`
+	ConnMaxLifetime time.Duration `validate:"min=0"`
+	ConnMaxIdleTime time.Duration `validate:"min=0"`
+	// LogLevel is silent, error, warn or info; info logs every statement.
+	LogLevel string `default:"warn" validate:"oneof=silent error warn info"`
+	// SlowThreshold logs statements running longer as slow; 0 disables slow query logging.
+	SlowThreshold time.Duration `default:"200ms" validate:"min=0"`
+	// LogParameters inlines the values bound to statements into the logged SQL.
+	// They can hold personal data and secrets, so logged SQL keeps its
+	// placeholders by default.
+	LogParameters bool
+}
+
+// GetConfig loads the database configuration from application.yaml.
+func GetConfig() (*Config, error) {
+	var cfg Config
+	if err := config.LoadConfig("application", []string{"."}, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	// Defaults are not applied to map values when loading.
+	for name, ds := range cfg.DataSources {
+		if err := config.ApplyDefaults(&ds); err != nil {
+			return nil, err
+		}
+		cfg.DataSources[name] = ds
+	}
+	return &cfg, nil
+}
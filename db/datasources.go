@@ -0,0 +1,103 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Conventional data source names.
+const (
+	PrimaryDataSource = "primary"
+	ReplicaDataSource = "replica"
+)
+
+// DataSources is a registry of named databases such as primary, replica and analytics.
+type DataSources struct {
+	mu      sync.RWMutex
+	sources map[string]*gorm.DB
+}
+
+// NewDataSources returns an empty registry.
+func NewDataSources() *DataSources {
+	return &DataSources{sources: make(map[string]*gorm.DB)}
+}
+
+// OpenDataSources opens every data source in cfg. The database section, when
+// it has a DSN, is the primary unless a data source is named primary.
+func OpenDataSources(cfg *Config) (*DataSources, error) {
+	sources := NewDataSources()
+	named := make(map[string]DataSourceConfig, len(cfg.DataSources)+1)
+	if cfg.Database.DSN != "" {
+		named[PrimaryDataSource] = cfg.Database
+	}
+	for name, dsCfg := range cfg.DataSources {
+		named[name] = dsCfg
+	}
+
+	for name, dsCfg := range named {
+		db, err := Open(dsCfg)
+		if err != nil {
+			_ = sources.Close()
+			return nil, fmt.Errorf("data source %s: %w", name, err)
+		}
+		sources.Add(name, db)
+	}
+	return sources, nil
+}
+
+// Add registers db under name, replacing any previous one.
+func (d *DataSources) Add(name string, db *gorm.DB) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sources[name] = db
+}
+
+// Get returns the data source registered under name.
+func (d *DataSources) Get(name string) (*gorm.DB, bool) {
+	if d == nil {
+		return nil, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	db, ok := d.sources[name]
+	return db, ok
+}
+
+// Primary returns the primary data source, or nil.
+func (d *DataSources) Primary() *gorm.DB {
+	db, _ := d.Get(PrimaryDataSource)
+	return db
+}
+
+// Names returns the sorted names of the registered data sources.
+func (d *DataSources) Names() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := make([]string, 0, len(d.sources))
+	for name := range d.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes the connection pools of all data sources.
+func (d *DataSources) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var errs []error
+	for name, db := range d.sources {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("data source %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
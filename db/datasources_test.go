@@ -0,0 +1,40 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenDataSources(t *testing.T) {
+	sqliteConfig := DataSourceConfig{Driver: DriverSQLite, DSN: "file::memory:", LogLevel: "silent"}
+	sources, err := OpenDataSources(&Config{
+		Database: sqliteConfig,
+		DataSources: map[string]DataSourceConfig{
+			ReplicaDataSource: sqliteConfig,
+			"analytics":       sqliteConfig,
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sources.Close() })
+
+	assert.Equal(t, []string{"analytics", PrimaryDataSource, ReplicaDataSource}, sources.Names())
+	require.NotNil(t, sources.Primary())
+	replica, ok := sources.Get(ReplicaDataSource)
+	require.True(t, ok)
+	assert.NotSame(t, sources.Primary(), replica)
+	_, ok = sources.Get("missing")
+	assert.False(t, ok)
+
+	var nilSources *DataSources
+	assert.Nil(t, nilSources.Primary())
+}
+
+func TestOpenDataSourcesError(t *testing.T) {
+	_, err := OpenDataSources(&Config{DataSources: map[string]DataSourceConfig{
+		"analytics": {Driver: "oracle", DSN: "x"},
+	}})
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+	assert.ErrorContains(t, err, "data source analytics")
+}
@@ -2,8 +2,24 @@ package db
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	DefaultDataSource *gorm.DB
 )
+
+// QuotedNames returns the identifiers as a comma-separated list of names quoted
+// for the statement's dialect, e.g. for TRUNCATE or SET search_path. A slice
+// variable would be rendered in parentheses instead.
+func QuotedNames(names ...string) clause.Expr {
+	expr := clause.Expr{Vars: make([]interface{}, len(names))}
+	for i, name := range names {
+		if i > 0 {
+			expr.SQL += ", "
+		}
+		expr.SQL += "?"
+		expr.Vars[i] = clause.Table{Name: name}
+	}
+	return expr
+}
@@ -0,0 +1,74 @@
+// Package dbtest provides in-memory SQLite databases for module tests.
+package dbtest
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/repository"
+	"gorm.io/gorm"
+)
+
+// Open returns a private in-memory SQLite database with the given models
+// migrated. It is closed when the test ends.
+func Open(tb testing.TB, models ...any) *gorm.DB {
+	tb.Helper()
+	gdb, err := db.Open(db.DataSourceConfig{
+		Driver: db.DriverSQLite,
+		// Every connection to :memory: opens a new database, so the pool
+		// keeps exactly one.
+		DSN:          "file::memory:",
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+		LogLevel:     "silent",
+	})
+	if err != nil {
+		tb.Fatalf("dbtest: open: %v", err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		tb.Fatalf("dbtest: %v", err)
+	}
+	tb.Cleanup(func() { _ = sqlDB.Close() })
+
+	if err := gdb.AutoMigrate(models...); err != nil {
+		tb.Fatalf("dbtest: migrate: %v", err)
+	}
+	return gdb
+}
+
+// Tx begins a transaction that is rolled back when the test ends, undoing
+// everything the test wrote. The returned context carries the transaction,
+// so repositories called with it, and TxManager.WithinTx through savepoints,
+// run inside it.
+func Tx(tb testing.TB, gdb *gorm.DB) (context.Context, *gorm.DB) {
+	tb.Helper()
+	tx := gdb.Begin()
+	if tx.Error != nil {
+		tb.Fatalf("dbtest: begin: %v", tx.Error)
+	}
+	tb.Cleanup(func() { tx.Rollback() })
+	return repository.ContextWithTx(context.Background(), tx), tx
+}
+
+// Truncate empties the tables of models now and again when the test ends.
+func Truncate(tb testing.TB, gdb *gorm.DB, models ...any) {
+	tb.Helper()
+	truncate := func() {
+		if err := db.TruncateTables(context.Background(), gdb, models...); err != nil {
+			tb.Errorf("dbtest: truncate: %v", err)
+		}
+	}
+	truncate()
+	tb.Cleanup(truncate)
+}
+
+// LoadFixtures loads the fixtures of models from dir in fsys; see db.LoadFixtures.
+func LoadFixtures(tb testing.TB, ctx context.Context, gdb *gorm.DB, fsys fs.FS, dir string, models ...any) {
+	tb.Helper()
+	if err := db.LoadFixtures(ctx, gdb, fsys, dir, models...); err != nil {
+		tb.Fatalf("dbtest: fixtures: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package dbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type note struct {
+	ID   int64
+	Text string
+}
+
+func TestTxRollsBackAtCleanup(t *testing.T) {
+	gdb := Open(t, &note{})
+	repo := repository.NewCrudRepository[note, int64](gdb)
+
+	t.Run("writes", func(t *testing.T) {
+		ctx, _ := Tx(t, gdb)
+		_, err := repo.Create(ctx, &note{Text: "scratch"})
+		require.NoError(t, err)
+		all, err := repo.ListAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	all, err := repo.ListAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestTruncate(t *testing.T) {
+	gdb := Open(t, &note{})
+	require.NoError(t, gdb.Create(&note{Text: "old"}).Error)
+
+	t.Run("starts empty", func(t *testing.T) {
+		Truncate(t, gdb, &note{})
+		var count int64
+		require.NoError(t, gdb.Model(&note{}).Count(&count).Error)
+		assert.Zero(t, count)
+		require.NoError(t, gdb.Create(&note{Text: "new"}).Error)
+	})
+
+	var count int64
+	require.NoError(t, gdb.Model(&note{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrFixtureCycle is returned when the fixture models depend on each other in a cycle.
+var ErrFixtureCycle = errors.New("fixture models have cyclic dependencies")
+
+// fixtureExtensions are tried in order for each table.
+var fixtureExtensions = []string{".yaml", ".yml", ".json"}
+
+// LoadFixtures inserts the rows of the given models from dir in fsys. Each
+// model's rows are read from a file named after its table, e.g. users.yaml,
+// users.yml or users.json, holding a list of objects whose keys are the field
+// names or JSON names of the model. Models without a file are skipped.
+//
+// Models are inserted in dependency order derived from their GORM
+// relationships, so a belongs-to parent is inserted before its children. All
+// rows are inserted in one transaction.
+func LoadFixtures(ctx context.Context, db *gorm.DB, fsys fs.FS, dir string, models ...any) error {
+	return loadFixtures(ctx, db, fsys, dir, false, models)
+}
+
+// loadFixtures implements LoadFixtures. With skipExisting, rows conflicting with
+// stored rows on their primary key or a unique column are skipped.
+func loadFixtures(ctx context.Context, db *gorm.DB, fsys fs.FS, dir string, skipExisting bool, models []any) error {
+	schemas, err := parseModels(db, models)
+	if err != nil {
+		return err
+	}
+	order, err := dependencyOrder(schemas)
+	if err != nil {
+		return err
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, i := range order {
+			rows, err := readFixture(fsys, dir, schemas[i])
+			if err != nil {
+				return err
+			}
+			if rows == nil || rows.Elem().Len() == 0 {
+				continue
+			}
+			create := tx.Omit(clause.Associations)
+			if skipExisting {
+				create = create.Clauses(clause.OnConflict{DoNothing: true})
+			}
+			if err := create.Create(rows.Interface()).Error; err != nil {
+				return fmt.Errorf("fixture %s: %w", schemas[i].Table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// readFixture decodes the fixture file of sch into a pointer to a slice of its
+// model, or returns nil when there is no file.
+func readFixture(fsys fs.FS, dir string, sch *schema.Schema) (*reflect.Value, error) {
+	for _, ext := range fixtureExtensions {
+		name := path.Join(dir, sch.Table+ext)
+		content, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var records []map[string]any
+		if ext == ".json" {
+			err = json.Unmarshal(content, &records)
+		} else {
+			err = yaml.Unmarshal(content, &records)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+		// Round-trip through JSON to decode the records into the model.
+		data, err := json.Marshal(records)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+		rows := reflect.New(reflect.SliceOf(sch.ModelType))
+		if err := json.Unmarshal(data, rows.Interface()); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", name, err)
+		}
+		return &rows, nil
+	}
+	return nil, nil
+}
+
+// parseModels returns the GORM schemas of models.
+func parseModels(db *gorm.DB, models []any) ([]*schema.Schema, error) {
+	schemas := make([]*schema.Schema, len(models))
+	for i, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		schemas[i] = stmt.Schema
+	}
+	return schemas, nil
+}
+
+// dependencyOrder returns the indexes of schemas sorted so that every schema
+// comes after the schemas it references. Ties keep the given order.
+func dependencyOrder(schemas []*schema.Schema) ([]int, error) {
+	index := make(map[string]int, len(schemas))
+	for i, sch := range schemas {
+		index[sch.Table] = i
+	}
+	deps := make([]map[int]bool, len(schemas))
+	for i := range deps {
+		deps[i] = make(map[int]bool)
+	}
+	for i, sch := range schemas {
+		for _, rel := range sch.Relationships.Relations {
+			j, ok := index[rel.FieldSchema.Table]
+			if !ok || j == i {
+				continue
+			}
+			switch rel.Type {
+			case schema.BelongsTo:
+				deps[i][j] = true
+			case schema.HasOne, schema.HasMany:
+				deps[j][i] = true
+			}
+		}
+	}
+
+	order := make([]int, 0, len(schemas))
+	done := make([]bool, len(schemas))
+	for len(order) < len(schemas) {
+		progressed := false
+		for i := range schemas {
+			if done[i] || !satisfied(deps[i], done) {
+				continue
+			}
+			done[i], progressed = true, true
+			order = append(order, i)
+		}
+		if !progressed {
+			var tables []string
+			for i, sch := range schemas {
+				if !done[i] {
+					tables = append(tables, sch.Table)
+				}
+			}
+			sort.Strings(tables)
+			return nil, fmt.Errorf("%w: %v", ErrFixtureCycle, tables)
+		}
+	}
+	return order, nil
+}
+
+func satisfied(deps map[int]bool, done []bool) bool {
+	for j := range deps {
+		if !done[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// TruncateTables deletes all rows of the given models' tables, children before
+// parents, and resets auto-increment counters where the driver supports it.
+func TruncateTables(ctx context.Context, db *gorm.DB, models ...any) error {
+	schemas, err := parseModels(db, models)
+	if err != nil {
+		return err
+	}
+	order, err := dependencyOrder(schemas)
+	if err != nil {
+		return err
+	}
+	tables := make([]string, len(order))
+	for i, j := range order {
+		tables[len(order)-1-i] = schemas[j].Table
+	}
+
+	db = db.WithContext(ctx)
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		return db.Exec("TRUNCATE TABLE ? RESTART IDENTITY CASCADE", QuotedNames(tables...)).Error
+	case DriverMySQL:
+		return db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+			for _, table := range tables {
+				if err := conn.Exec("TRUNCATE TABLE ?", clause.Table{Name: table}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			if err := tx.Exec("DELETE FROM ?", clause.Table{Name: table}).Error; err != nil {
+				return err
+			}
+		}
+		if tx.Dialector.Name() == DriverSQLite && tx.Migrator().HasTable("sqlite_sequence") {
+			return tx.Exec("DELETE FROM sqlite_sequence WHERE name IN ?", tables).Error
+		}
+		return nil
+	})
+}
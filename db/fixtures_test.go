@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type fixtureCustomer struct {
+	ID     uint
+	Name   string
+	Orders []fixtureOrder `gorm:"foreignKey:CustomerID"`
+}
+
+type fixtureOrder struct {
+	ID         uint
+	CustomerID uint
+	Customer   *fixtureCustomer
+	Total      int
+	PlacedAt   time.Time
+}
+
+type fixtureLine struct {
+	ID      uint
+	OrderID uint
+	Order   *fixtureOrder
+	SKU     string `json:"sku"`
+}
+
+var testFixtures = fstest.MapFS{
+	"fixtures/fixture_customers.yaml": {Data: []byte(`
+- id: 1
+  name: Ada
+- id: 2
+  name: Grace
+`)},
+	"fixtures/fixture_orders.yml": {Data: []byte(`
+- id: 10
+  customerid: 1
+  total: 42
+  placedat: 2024-05-01T10:00:00Z
+`)},
+	"fixtures/fixture_lines.json": {Data: []byte(`[{"ID": 100, "OrderID": 10, "sku": "BRICK-1"}]`)},
+}
+
+func newFixtureDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openTestDB(t)
+	require.NoError(t, db.Exec("PRAGMA foreign_keys = ON").Error)
+	require.NoError(t, db.AutoMigrate(&fixtureCustomer{}, &fixtureOrder{}, &fixtureLine{}))
+	return db
+}
+
+func TestLoadFixturesInDependencyOrder(t *testing.T) {
+	db := newFixtureDB(t)
+	ctx := context.Background()
+
+	// Children are listed first; foreign keys fail unless parents are inserted first.
+	err := LoadFixtures(ctx, db, testFixtures, "fixtures", &fixtureLine{}, &fixtureOrder{}, &fixtureCustomer{})
+	require.NoError(t, err)
+
+	var customers []fixtureCustomer
+	require.NoError(t, db.Order("id").Find(&customers).Error)
+	require.Len(t, customers, 2)
+	assert.Equal(t, "Grace", customers[1].Name)
+
+	var order fixtureOrder
+	require.NoError(t, db.Preload("Customer").First(&order, 10).Error)
+	assert.Equal(t, "Ada", order.Customer.Name)
+	assert.Equal(t, 42, order.Total)
+	assert.True(t, order.PlacedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	var line fixtureLine
+	require.NoError(t, db.First(&line, 100).Error)
+	assert.Equal(t, "BRICK-1", line.SKU)
+}
+
+func TestLoadFixturesRollsBackOnError(t *testing.T) {
+	db := newFixtureDB(t)
+	broken := fstest.MapFS{
+		"f/fixture_customers.yaml": {Data: []byte("- id: 1\n  name: Ada\n")},
+		"f/fixture_orders.yaml":    {Data: []byte("- id: 1\n  customerid: 99\n")},
+	}
+
+	err := LoadFixtures(context.Background(), db, broken, "f", &fixtureCustomer{}, &fixtureOrder{})
+	assert.ErrorContains(t, err, "fixture fixture_orders")
+	var count int64
+	require.NoError(t, db.Model(&fixtureCustomer{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+type cycleA struct {
+	ID  uint
+	BID uint
+	B   *cycleB
+}
+
+type cycleB struct {
+	ID  uint
+	AID uint
+	A   *cycleA
+}
+
+func TestDependencyOrderCycle(t *testing.T) {
+	err := LoadFixtures(context.Background(), openTestDB(t), fstest.MapFS{}, ".", &cycleA{}, &cycleB{})
+	assert.ErrorIs(t, err, ErrFixtureCycle)
+}
+
+func TestTruncateTables(t *testing.T) {
+	db := newFixtureDB(t)
+	ctx := context.Background()
+	require.NoError(t, LoadFixtures(ctx, db, testFixtures, "fixtures", &fixtureCustomer{}, &fixtureOrder{}, &fixtureLine{}))
+
+	require.NoError(t, TruncateTables(ctx, db, &fixtureCustomer{}, &fixtureOrder{}, &fixtureLine{}))
+	for _, model := range []any{&fixtureCustomer{}, &fixtureOrder{}, &fixtureLine{}} {
+		var count int64
+		require.NoError(t, db.Model(model).Count(&count).Error)
+		assert.Zero(t, count)
+	}
+}
+
+// sqlRecorder is a GORM logger recording the traced statements.
+type sqlRecorder struct {
+	gormlogger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func TestTruncateTablesPostgres(t *testing.T) {
+	recorder := &sqlRecorder{Interface: gormlogger.Discard}
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: recorder})
+	require.NoError(t, err)
+
+	require.NoError(t, TruncateTables(context.Background(), db, &fixtureCustomer{}, &fixtureOrder{}, &fixtureLine{}))
+	assert.Equal(t, []string{
+		`TRUNCATE TABLE "fixture_lines", "fixture_orders", "fixture_customers" RESTART IDENTITY CASCADE`,
+	}, recorder.statements)
+}
+
+func TestRunSeeders(t *testing.T) {
+	db := newFixtureDB(t)
+	ctx := context.Background()
+	seeders := []Seeder{
+		FixtureSeeder("customers", testFixtures, "fixtures", &fixtureCustomer{}),
+		{Name: "demo", Profiles: []string{"demo"}, Run: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Create(&fixtureCustomer{Name: "Demo"}).Error
+		}},
+	}
+
+	ran, err := RunSeeders(ctx, db, "production", seeders...)
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+
+	ran, err = RunSeeders(ctx, db, "test", seeders...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customers"}, ran)
+
+	// Fixture rows already stored are skipped on the next start.
+	ran, err = RunSeeders(ctx, db, "test", seeders...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customers"}, ran)
+
+	ran, err = RunSeeders(ctx, db, "demo", seeders...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"demo"}, ran)
+
+	var count int64
+	require.NoError(t, db.Model(&fixtureCustomer{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// LoggerName is the name of the logger GORM writes to, so that its level can
+// be set on its own, e.g. "info,db=debug".
+const LoggerName = "db"
+
+// gormLogger implements gorm's logger.Interface on top of logger.Logger.
+type gormLogger struct {
+	config gormlogger.Config
+}
+
+// NewGormLogger returns a GORM logger writing to the logger carried by the
+// statement context, so entries include its request and tenant fields, or to
+// logger.DefaultLogger. Statements are logged at debug level when config.LogLevel
+// is Info, statements slower than config.SlowThreshold at warn level and failed
+// statements at error level.
+func NewGormLogger(config gormlogger.Config) gormlogger.Interface {
+	return &gormLogger{config: config}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.config.LogLevel = level
+	return &clone
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Info {
+		l.logger(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Warn {
+		l.logger(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Error {
+		l.logger(ctx).Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	slow := l.config.SlowThreshold != 0 && elapsed > l.config.SlowThreshold
+	failed := err != nil && !(l.config.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound))
+
+	switch {
+	case failed && l.config.LogLevel >= gormlogger.Error:
+		l.logger(ctx).Error("Query failed", append(l.fields(fc, elapsed), logger.Error(err))...)
+	case slow && l.config.LogLevel >= gormlogger.Warn:
+		fields := append(l.fields(fc, elapsed), logger.Any("threshold", l.config.SlowThreshold))
+		l.logger(ctx).Warn("Slow query", fields...)
+	case l.config.LogLevel >= gormlogger.Info:
+		l.logger(ctx).Debug("Query", l.fields(fc, elapsed)...)
+	}
+}
+
+// ParamsFilter implements gorm's logger.ParamsFilter, hiding the values bound
+// to statements when config.ParameterizedQueries is set.
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.config.ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func (l *gormLogger) logger(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx).Named(LoggerName)
+}
+
+func (l *gormLogger) fields(fc func() (string, int64), elapsed time.Duration) []logger.Field {
+	sql, rows := fc()
+	fields := []logger.Field{
+		logger.String("sql", sql),
+		logger.Any("duration", elapsed),
+		logger.String("caller", utils.FileWithLineNum()),
+	}
+	if rows >= 0 {
+		fields = append(fields, logger.Any("rows", rows))
+	}
+	return fields
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// fieldMap collects logged fields by key.
+func fieldMap(fields []logger.Field) map[string]any {
+	m := make(map[string]any, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	return m
+}
+
+func newLoggerContext(t *testing.T) (context.Context, *logger.MockLogger) {
+	ctrl := gomock.NewController(t)
+	scoped := logger.NewMockLogger(ctrl)
+	named := logger.NewMockLogger(ctrl)
+	scoped.EXPECT().Named(LoggerName).Return(named).AnyTimes()
+	return logger.IntoContext(context.Background(), scoped), named
+}
+
+func TestGormLoggerLogsStatementsWithContextLogger(t *testing.T) {
+	ctx, named := newLoggerContext(t)
+	var got map[string]any
+	named.EXPECT().Debug("Query", gomock.Any()).Do(func(_ string, fields ...logger.Field) {
+		got = fieldMap(fields)
+	})
+
+	db := openTestDB(t)
+	db.Logger = NewGormLogger(gormlogger.Config{LogLevel: gormlogger.Info})
+	var one int
+	require.NoError(t, db.WithContext(ctx).Raw("SELECT ?", 1).Scan(&one).Error)
+
+	assert.Equal(t, "SELECT 1", got["sql"])
+	assert.Contains(t, got, "duration")
+	assert.Contains(t, got, "rows")
+}
+
+func TestGormLoggerErrorsAndSlowQueries(t *testing.T) {
+	ctx, named := newLoggerContext(t)
+	l := NewGormLogger(gormlogger.Config{
+		LogLevel:                  gormlogger.Warn,
+		SlowThreshold:             10 * time.Millisecond,
+		IgnoreRecordNotFoundError: true,
+	})
+	fc := func() (string, int64) { return "SELECT * FROM items", 3 }
+
+	named.EXPECT().Error("Query failed", gomock.Any()).Do(func(_ string, fields ...logger.Field) {
+		assert.Equal(t, "SELECT * FROM items", fieldMap(fields)["sql"])
+	})
+	l.Trace(ctx, time.Now(), fc, errors.New("boom"))
+
+	named.EXPECT().Warn("Slow query", gomock.Any()).Do(func(_ string, fields ...logger.Field) {
+		m := fieldMap(fields)
+		assert.Equal(t, int64(3), m["rows"])
+		assert.Equal(t, 10*time.Millisecond, m["threshold"])
+	})
+	l.Trace(ctx, time.Now().Add(-time.Second), fc, nil)
+
+	// Fast statements below Info and ignored errors are not logged.
+	l.Trace(ctx, time.Now(), fc, nil)
+	l.Trace(ctx, time.Now(), fc, gorm.ErrRecordNotFound)
+	l.LogMode(gormlogger.Silent).Trace(ctx, time.Now(), fc, errors.New("boom"))
+}
+
+type meteredItem struct {
+	ID   uint
+	Name string
+}
+
+func TestQueryMetrics(t *testing.T) {
+	db, err := Open(DataSourceConfig{Driver: DriverSQLite, DSN: "file::memory:", MaxOpenConns: 1, MaxIdleConns: 1, LogLevel: "silent"})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&meteredItem{}))
+
+	metrics, ok := MetricsOf(db)
+	require.True(t, ok)
+	metrics.Reset()
+
+	require.NoError(t, db.Create(&meteredItem{Name: "a"}).Error)
+	require.NoError(t, db.Create(&meteredItem{Name: "b"}).Error)
+	var item meteredItem
+	require.NoError(t, db.First(&item).Error)
+	assert.ErrorIs(t, db.Where("name = ?", "missing").First(&item).Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.Table("missing").Find(&[]meteredItem{}).Error)
+	require.NoError(t, db.Model(&item).Update("name", "c").Error)
+
+	stats := metrics.Stats()
+	created := stats[QueryKey{Table: "metered_items", Operation: "create"}]
+	assert.Equal(t, int64(2), created.Count)
+	assert.Positive(t, created.TotalDuration)
+	assert.GreaterOrEqual(t, created.TotalDuration, created.MaxDuration)
+	queried := stats[QueryKey{Table: "metered_items", Operation: "query"}]
+	assert.Equal(t, int64(2), queried.Count)
+	assert.Zero(t, queried.Errors)
+	assert.Equal(t, int64(1), stats[QueryKey{Table: "missing", Operation: "query"}].Errors)
+	assert.Equal(t, int64(1), stats[QueryKey{Table: "metered_items", Operation: "update"}].Count)
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// metricsStartKey is the statement setting holding the start of a query.
+const metricsStartKey = "ebrick:metrics_start"
+
+// QueryKey identifies the queries counted together.
+type QueryKey struct {
+	Table     string
+	Operation string
+}
+
+// QueryStats are the counters of queries with the same table and operation.
+type QueryStats struct {
+	Count         int64
+	Errors        int64
+	Slow          int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// QueryMetrics is a GORM plugin counting queries, errors and slow queries per
+// table and operation: create, query, update, delete, row or raw.
+type QueryMetrics struct {
+	// SlowThreshold counts queries running longer as slow; 0 disables counting.
+	SlowThreshold time.Duration
+
+	mu    sync.Mutex
+	stats map[QueryKey]*QueryStats
+}
+
+// NewQueryMetrics returns the plugin; register it with db.Use.
+func NewQueryMetrics(slowThreshold time.Duration) *QueryMetrics {
+	return &QueryMetrics{SlowThreshold: slowThreshold, stats: make(map[QueryKey]*QueryStats)}
+}
+
+// MetricsOf returns the QueryMetrics registered on db.
+func MetricsOf(db *gorm.DB) (*QueryMetrics, bool) {
+	m, ok := db.Config.Plugins[(*QueryMetrics)(nil).Name()].(*QueryMetrics)
+	return m, ok
+}
+
+// Name implements gorm.Plugin.
+func (m *QueryMetrics) Name() string {
+	return "ebrick:metrics"
+}
+
+// Initialize implements gorm.Plugin.
+func (m *QueryMetrics) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	register := []struct {
+		op     string
+		before func(string) error
+		after  func(string) error
+	}{
+		{"create", func(n string) error { return cb.Create().Before("gorm:create").Register(n, m.start) },
+			func(n string) error { return cb.Create().After("gorm:create").Register(n, m.record("create")) }},
+		{"query", func(n string) error { return cb.Query().Before("gorm:query").Register(n, m.start) },
+			func(n string) error { return cb.Query().After("gorm:query").Register(n, m.record("query")) }},
+		{"update", func(n string) error { return cb.Update().Before("gorm:update").Register(n, m.start) },
+			func(n string) error { return cb.Update().After("gorm:update").Register(n, m.record("update")) }},
+		{"delete", func(n string) error { return cb.Delete().Before("gorm:delete").Register(n, m.start) },
+			func(n string) error { return cb.Delete().After("gorm:delete").Register(n, m.record("delete")) }},
+		{"row", func(n string) error { return cb.Row().Before("gorm:row").Register(n, m.start) },
+			func(n string) error { return cb.Row().After("gorm:row").Register(n, m.record("row")) }},
+		{"raw", func(n string) error { return cb.Raw().Before("gorm:raw").Register(n, m.start) },
+			func(n string) error { return cb.Raw().After("gorm:raw").Register(n, m.record("raw")) }},
+	}
+	for _, r := range register {
+		if err := r.before("ebrick:metrics_before_" + r.op); err != nil {
+			return err
+		}
+		if err := r.after("ebrick:metrics_after_" + r.op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the counters.
+func (m *QueryMetrics) Stats() map[QueryKey]QueryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[QueryKey]QueryStats, len(m.stats))
+	for k, s := range m.stats {
+		stats[k] = *s
+	}
+	return stats
+}
+
+// Reset clears the counters.
+func (m *QueryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = make(map[QueryKey]*QueryStats)
+}
+
+func (m *QueryMetrics) start(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (m *QueryMetrics) record(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(v.(time.Time))
+		key := QueryKey{Table: db.Statement.Table, Operation: op}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		s, ok := m.stats[key]
+		if !ok {
+			s = &QueryStats{}
+			m.stats[key] = s
+		}
+		s.Count++
+		s.TotalDuration += elapsed
+		if elapsed > s.MaxDuration {
+			s.MaxDuration = elapsed
+		}
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			s.Errors++
+		}
+		if m.SlowThreshold != 0 && elapsed > m.SlowThreshold {
+			s.Slow++
+		}
+	}
+}
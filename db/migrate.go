@@ -0,0 +1,463 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"gorm.io/gorm"
+)
+
+// DefaultMigrationTable records the applied migrations.
+const DefaultMigrationTable = "schema_migrations"
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration was modified afterwards.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrIrreversibleMigration is returned when rolling back a migration without a down step.
+	ErrIrreversibleMigration = errors.New("migration is irreversible")
+	// ErrInvalidMigration is returned when registering or loading a malformed migration.
+	ErrInvalidMigration = errors.New("invalid migration")
+	// ErrMigrationLocked is returned when the migration lock cannot be acquired.
+	ErrMigrationLocked = errors.New("migration lock not acquired")
+)
+
+// MigrateFunc is a migration step written in Go. It runs in the migration's transaction.
+type MigrateFunc func(ctx context.Context, tx *gorm.DB) error
+
+// Migration is a versioned schema change. Each direction is either a Go
+// function or SQL; the function takes precedence when both are set. SQL
+// holding several statements requires a driver that supports them in a
+// single Exec, e.g. multiStatements=true for MySQL.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      MigrateFunc
+	Down    MigrateFunc
+	UpSQL   string
+	DownSQL string
+}
+
+// Checksum identifies the migration's content, so that changes to applied
+// migrations are detected. Go migrations are identified by version and name.
+func (m Migration) Checksum() string {
+	content := m.UpSQL
+	if m.Up != nil || content == "" {
+		content = strconv.FormatInt(m.Version, 10) + ":" + m.Name
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m Migration) up(ctx context.Context, tx *gorm.DB) error {
+	if m.Up != nil {
+		return m.Up(ctx, tx)
+	}
+	return tx.Exec(m.UpSQL).Error
+}
+
+func (m Migration) down(ctx context.Context, tx *gorm.DB) error {
+	switch {
+	case m.Down != nil:
+		return m.Down(ctx, tx)
+	case m.DownSQL != "":
+		return tx.Exec(m.DownSQL).Error
+	}
+	return fmt.Errorf("%w: %d_%s", ErrIrreversibleMigration, m.Version, m.Name)
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads SQL migrations from dir in fsys, typically an embed.FS.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql;
+// down files are optional. Other files are ignored.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidMigration, entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %s and %s", ErrInvalidMigration, version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("%w: %d_%s has no up file", ErrInvalidMigration, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationSource is implemented by modules contributing their own migrations.
+type MigrationSource interface {
+	Migrations() ([]Migration, error)
+}
+
+// MigrationRecord is a row of the migration table.
+type MigrationRecord struct {
+	Module    string `gorm:"primaryKey;size:128"`
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	Checksum  string `gorm:"size:64;not null"`
+	AppliedAt time.Time
+}
+
+// MigrationStatus reports the state of a migration.
+type MigrationStatus struct {
+	Module    string
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Modified is set when the applied migration no longer matches its checksum.
+	Modified bool
+	// Missing is set when an applied migration is no longer registered.
+	Missing bool
+}
+
+// MigratorOptions configures a Migrator.
+type MigratorOptions struct {
+	// Table records the applied migrations; DefaultMigrationTable by default.
+	Table string
+	// DryRun reports the migrations Up and Down would run without running them.
+	DryRun bool
+	// LockTimeout bounds the wait for the MySQL migration lock.
+	LockTimeout time.Duration
+}
+
+type MigratorOption func(*MigratorOptions)
+
+// WithMigrationTable sets the table recording the applied migrations.
+func WithMigrationTable(table string) MigratorOption {
+	return func(o *MigratorOptions) {
+		o.Table = table
+	}
+}
+
+// WithDryRun makes Up and Down report what they would run without running it.
+func WithDryRun() MigratorOption {
+	return func(o *MigratorOptions) {
+		o.DryRun = true
+	}
+}
+
+// WithLockTimeout bounds the wait for the migration lock.
+func WithLockTimeout(timeout time.Duration) MigratorOption {
+	return func(o *MigratorOptions) {
+		o.LockTimeout = timeout
+	}
+}
+
+// Migrator applies and rolls back versioned migrations, grouped by module.
+// Runs hold a database lock, a PostgreSQL advisory lock or a MySQL named lock,
+// so that replicas starting together migrate only once. Each migration runs
+// in its own transaction together with its record in the migration table.
+type Migrator struct {
+	db      *gorm.DB
+	options MigratorOptions
+	modules []string
+	sets    map[string][]Migration
+}
+
+// NewMigrator returns a Migrator on db.
+func NewMigrator(db *gorm.DB, opts ...MigratorOption) *Migrator {
+	options := MigratorOptions{Table: DefaultMigrationTable, LockTimeout: time.Minute}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Migrator{db: db, options: options, sets: make(map[string][]Migration)}
+}
+
+// Register adds the migrations of module. Modules are migrated in the order
+// they are registered and their migrations in version order.
+func (m *Migrator) Register(module string, migrations ...Migration) error {
+	set := append(append([]Migration(nil), m.sets[module]...), migrations...)
+	sort.Slice(set, func(i, j int) bool { return set[i].Version < set[j].Version })
+	for i, mig := range set {
+		if mig.Version <= 0 {
+			return fmt.Errorf("%w: %s: version must be positive", ErrInvalidMigration, module)
+		}
+		if mig.Up == nil && mig.UpSQL == "" {
+			return fmt.Errorf("%w: %s: %d_%s has no up step", ErrInvalidMigration, module, mig.Version, mig.Name)
+		}
+		if i > 0 && set[i-1].Version == mig.Version {
+			return fmt.Errorf("%w: %s: duplicate version %d", ErrInvalidMigration, module, mig.Version)
+		}
+	}
+	if _, ok := m.sets[module]; !ok {
+		m.modules = append(m.modules, module)
+	}
+	m.sets[module] = set
+	return nil
+}
+
+// Status reports every registered migration and every applied migration that
+// is no longer registered.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return m.status(applied), nil
+}
+
+// Up applies the pending migrations and returns them. Applied migrations whose
+// checksum changed fail the run with ErrChecksumMismatch before anything is applied.
+func (m *Migrator) Up(ctx context.Context) ([]MigrationStatus, error) {
+	var done []MigrationStatus
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		for _, st := range m.status(applied) {
+			if st.Modified {
+				return fmt.Errorf("%w: %s %d_%s", ErrChecksumMismatch, st.Module, st.Version, st.Name)
+			}
+		}
+
+		for _, module := range m.modules {
+			for _, mig := range m.sets[module] {
+				if _, ok := applied[module][mig.Version]; ok {
+					continue
+				}
+				if err := m.run(ctx, conn, module, mig, true); err != nil {
+					return err
+				}
+				done = append(done, MigrationStatus{Module: module, Version: mig.Version, Name: mig.Name, Applied: !m.options.DryRun})
+			}
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations of module and returns them.
+func (m *Migrator) Down(ctx context.Context, module string, steps int) ([]MigrationStatus, error) {
+	var done []MigrationStatus
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		set := m.sets[module]
+		for i := len(set) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := set[i]
+			if _, ok := applied[module][mig.Version]; !ok {
+				continue
+			}
+			if err := m.run(ctx, conn, module, mig, false); err != nil {
+				return err
+			}
+			done = append(done, MigrationStatus{Module: module, Version: mig.Version, Name: mig.Name, Applied: m.options.DryRun})
+		}
+		return nil
+	})
+	return done, err
+}
+
+// run applies or rolls back mig in a transaction on conn.
+func (m *Migrator) run(ctx context.Context, conn *gorm.DB, module string, mig Migration, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	log := logger.FromContext(ctx)
+	fields := []logger.Field{
+		logger.String("module", module),
+		logger.Any("version", mig.Version),
+		logger.String("name", mig.Name),
+		logger.String("direction", direction),
+	}
+	if m.options.DryRun {
+		log.Info("Pending migration (dry run)", fields...)
+		return nil
+	}
+
+	start := time.Now()
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if !up {
+			if err := mig.down(ctx, tx); err != nil {
+				return err
+			}
+			return tx.Table(m.options.Table).Where("module = ? AND version = ?", module, mig.Version).
+				Delete(&MigrationRecord{}).Error
+		}
+		if err := mig.up(ctx, tx); err != nil {
+			return err
+		}
+		return tx.Table(m.options.Table).Create(&MigrationRecord{
+			Module:    module,
+			Version:   mig.Version,
+			Name:      mig.Name,
+			Checksum:  mig.Checksum(),
+			AppliedAt: time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		log.Error("Migration failed", append(fields, logger.Error(err))...)
+		return fmt.Errorf("migration %s %d_%s %s: %w", module, mig.Version, mig.Name, direction, err)
+	}
+	log.Info("Migration applied", append(fields, logger.Any("duration", time.Since(start)))...)
+	return nil
+}
+
+// status merges the registered migrations with the applied records.
+func (m *Migrator) status(applied map[string]map[int64]MigrationRecord) []MigrationStatus {
+	var statuses []MigrationStatus
+	for _, module := range m.modules {
+		registered := make(map[int64]bool, len(m.sets[module]))
+		for _, mig := range m.sets[module] {
+			registered[mig.Version] = true
+			st := MigrationStatus{Module: module, Version: mig.Version, Name: mig.Name}
+			if rec, ok := applied[module][mig.Version]; ok {
+				appliedAt := rec.AppliedAt
+				st.Applied, st.AppliedAt = true, &appliedAt
+				st.Modified = rec.Checksum != mig.Checksum()
+			}
+			statuses = append(statuses, st)
+		}
+		statuses = append(statuses, missing(module, applied[module], registered)...)
+	}
+	var unregistered []string
+	for module := range applied {
+		if _, ok := m.sets[module]; !ok {
+			unregistered = append(unregistered, module)
+		}
+	}
+	sort.Strings(unregistered)
+	for _, module := range unregistered {
+		statuses = append(statuses, missing(module, applied[module], nil)...)
+	}
+	return statuses
+}
+
+// missing returns the applied records that are not registered.
+func missing(module string, records map[int64]MigrationRecord, registered map[int64]bool) []MigrationStatus {
+	var statuses []MigrationStatus
+	for version, rec := range records {
+		if registered[version] {
+			continue
+		}
+		appliedAt := rec.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Module: module, Version: version, Name: rec.Name, Applied: true, AppliedAt: &appliedAt, Missing: true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// ensureTable creates the migration table, except in a dry run, which changes
+// nothing; applied treats a missing table as empty.
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	if m.options.DryRun {
+		return nil
+	}
+	return db.Table(m.options.Table).AutoMigrate(&MigrationRecord{})
+}
+
+// applied returns the applied records by module and version.
+func (m *Migrator) applied(db *gorm.DB) (map[string]map[int64]MigrationRecord, error) {
+	applied := make(map[string]map[int64]MigrationRecord)
+	if !db.Migrator().HasTable(m.options.Table) {
+		return applied, nil
+	}
+	var records []MigrationRecord
+	if err := db.Table(m.options.Table).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		if applied[rec.Module] == nil {
+			applied[rec.Module] = make(map[int64]MigrationRecord)
+		}
+		applied[rec.Module][rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock.
+// SQLite serializes writers itself and needs no lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	locked := func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case "postgres":
+			key := m.lockKey()
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", key).Error; err != nil {
+				return fmt.Errorf("%w: %v", ErrMigrationLocked, err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+		case "mysql":
+			var locked int
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", m.options.Table, int(m.options.LockTimeout.Seconds())).
+				Scan(&locked).Error; err != nil {
+				return fmt.Errorf("%w: %v", ErrMigrationLocked, err)
+			}
+			if locked != 1 {
+				return ErrMigrationLocked
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", m.options.Table)
+		}
+		return fn(conn)
+	}
+	// A database pinned to a connection, e.g. one set to a tenant's schema, keeps it.
+	if _, ok := m.db.Statement.ConnPool.(*sql.Conn); ok {
+		return locked(m.db.WithContext(ctx))
+	}
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// A session keeps the statements of the migrator, e.g. on its table,
+		// from leaking into those of Go migrations.
+		return locked(conn.Session(&gorm.Session{}))
+	})
+}
+
+// lockKey derives the advisory lock key from the migration table name.
+func (m *Migrator) lockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(m.options.Table))
+	return int64(h.Sum64())
+}
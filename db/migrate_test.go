@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);")},
+	"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;")},
+	"migrations/0002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+func seedUsers(ctx context.Context, tx *gorm.DB) error {
+	return tx.Exec("INSERT INTO users (name, email) VALUES ('admin', 'admin@example.com')").Error
+}
+
+func newTestMigrator(t *testing.T, db *gorm.DB, opts ...MigratorOption) *Migrator {
+	t.Helper()
+	migrations, err := LoadMigrations(testMigrations, "migrations")
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	m := NewMigrator(db, opts...)
+	require.NoError(t, m.Register("users", migrations...))
+	require.NoError(t, m.Register("users", Migration{Version: 3, Name: "seed_admin", Up: seedUsers}))
+	require.NoError(t, m.Register("billing", Migration{
+		Version: 1,
+		Name:    "create_invoices",
+		UpSQL:   "CREATE TABLE invoices (id INTEGER PRIMARY KEY);",
+	}))
+	return m
+}
+
+func TestMigratorUpAndStatus(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := newTestMigrator(t, db)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 4)
+	assert.Equal(t, "users", applied[0].Module)
+	assert.Equal(t, "billing", applied[3].Module)
+
+	var email string
+	require.NoError(t, db.Raw("SELECT email FROM users WHERE name = 'admin'").Scan(&email).Error)
+	assert.Equal(t, "admin@example.com", email)
+
+	// A second run, e.g. by another replica, applies nothing.
+	applied, err = newTestMigrator(t, db).Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	for _, st := range statuses {
+		assert.True(t, st.Applied, st.Name)
+		assert.NotNil(t, st.AppliedAt)
+		assert.False(t, st.Modified)
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := newTestMigrator(t, db)
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = m.Down(ctx, "users", 1)
+	assert.ErrorIs(t, err, ErrIrreversibleMigration)
+
+	require.NoError(t, db.Exec("DELETE FROM users").Error)
+	m.sets["users"][2].Down = func(context.Context, *gorm.DB) error { return nil }
+	rolledBack, err := m.Down(ctx, "users", 2)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, 2)
+	assert.Equal(t, int64(3), rolledBack[0].Version)
+	assert.Equal(t, int64(2), rolledBack[1].Version)
+	assert.False(t, db.Migrator().HasColumn("users", "email"))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	pending, err := newTestMigrator(t, db, WithDryRun()).Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 4)
+	assert.False(t, pending[0].Applied)
+	assert.False(t, db.Migrator().HasTable("users"))
+	assert.False(t, db.Migrator().HasTable(DefaultMigrationTable))
+
+	_, err = newTestMigrator(t, db, WithDryRun()).Down(ctx, "users", 1)
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable(DefaultMigrationTable))
+}
+
+func TestMigratorChecksumMismatch(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	_, err := newTestMigrator(t, db).Up(ctx)
+	require.NoError(t, err)
+
+	m := NewMigrator(db)
+	require.NoError(t, m.Register("billing",
+		Migration{Version: 1, Name: "create_invoices", UpSQL: "CREATE TABLE invoices (id INTEGER PRIMARY KEY, total INTEGER);"},
+		Migration{Version: 2, Name: "create_payments", UpSQL: "CREATE TABLE payments (id INTEGER PRIMARY KEY);"},
+	))
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.False(t, db.Migrator().HasTable("payments"))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Modified)
+	// The users migrations are applied but not registered with this migrator.
+	require.Len(t, statuses, 5)
+	assert.True(t, statuses[2].Missing)
+	assert.Equal(t, "users", statuses[2].Module)
+}
+
+func TestMigratorFailedMigrationRollsBack(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db)
+	require.NoError(t, m.Register("broken", Migration{
+		Version: 1,
+		Name:    "broken",
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE half (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	}))
+
+	_, err := m.Up(ctx)
+	assert.ErrorContains(t, err, "boom")
+	assert.False(t, db.Migrator().HasTable("half"))
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Applied)
+}
+
+type migratedUser struct {
+	ID   int64
+	Name string
+}
+
+func (migratedUser) TableName() string { return "users" }
+
+func TestMigratorGoMigrationsUseModels(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := newTestMigrator(t, db)
+	require.NoError(t, m.Register("users", Migration{Version: 4, Name: "seed_user", Up: func(ctx context.Context, tx *gorm.DB) error {
+		return tx.Create(&migratedUser{Name: "bob"}).Error
+	}}))
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 5)
+	var count int64
+	require.NoError(t, db.Model(&migratedUser{}).Where("name = ?", "bob").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMigratorRejectsInvalidMigrations(t *testing.T) {
+	m := NewMigrator(nil)
+	assert.ErrorIs(t, m.Register("a", Migration{Version: 1, Name: "x"}), ErrInvalidMigration)
+	assert.ErrorIs(t, m.Register("a", Migration{Version: 0, Name: "x", UpSQL: "SELECT 1"}), ErrInvalidMigration)
+	assert.ErrorIs(t, m.Register("a",
+		Migration{Version: 1, Name: "x", UpSQL: "SELECT 1"},
+		Migration{Version: 1, Name: "y", UpSQL: "SELECT 1"},
+	), ErrInvalidMigration)
+
+	_, err := LoadMigrations(fstest.MapFS{"m/0001_x.down.sql": {Data: []byte("SELECT 1")}}, "m")
+	assert.ErrorIs(t, err, ErrInvalidMigration)
+}
+
+func TestMigratorOnPinnedConnection(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	err := db.Connection(func(conn *gorm.DB) error {
+		applied, err := newTestMigrator(t, conn).Up(ctx)
+		assert.Len(t, applied, 4)
+		return err
+	})
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable("invoices"))
+}
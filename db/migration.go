@@ -29,7 +29,13 @@ func DropTables(db *gorm.DB, models ...interface{}) error {
 	return nil
 }
 
+// CreateEnumTypes runs the given CREATE TYPE ... AS ENUM statements for types
+// that do not exist yet. Enum types are PostgreSQL specific; other drivers
+// return ErrUnsupportedDriver.
 func CreateEnumTypes(db *gorm.DB, enumStatements ...string) error {
+	if name := db.Dialector.Name(); name != DriverPostgres {
+		return fmt.Errorf("%w: %s has no enum types", ErrUnsupportedDriver, name)
+	}
 	// List of ENUM creation statements
 	for _, stmt := range enumStatements {
 		// Execute the statement if the type does not exist
@@ -42,7 +48,10 @@ func CreateEnumTypes(db *gorm.DB, enumStatements ...string) error {
 				WHERE typname = $1
 			);
 		`
-		typeName := getTypeNameFromCreateStmt(stmt)
+		typeName, err := getTypeNameFromCreateStmt(stmt)
+		if err != nil {
+			return err
+		}
 		if err := db.Raw(checkStmt, typeName).Scan(&exists).Error; err != nil {
 			return err
 		}
@@ -61,13 +70,13 @@ func CreateEnumTypes(db *gorm.DB, enumStatements ...string) error {
 }
 
 // Helper function to extract type name from CREATE TYPE statement
-func getTypeNameFromCreateStmt(stmt string) string {
+func getTypeNameFromCreateStmt(stmt string) (string, error) {
 	var typeName string
 	_, err := fmt.Sscanf(stmt, "CREATE TYPE %s AS ENUM", &typeName)
 	if err != nil {
-		log.Fatalf("Failed to parse ENUM type name from statement: %s", stmt)
+		return "", fmt.Errorf("failed to parse ENUM type name from statement %q: %w", stmt, err)
 	}
 	// Remove any trailing characters like space or quotes
 	typeName = strings.Trim(typeName, " '\"")
-	return typeName
+	return typeName, nil
 }
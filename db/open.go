@@ -0,0 +1,98 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Supported drivers.
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+)
+
+var (
+	// ErrNoDSN is returned when opening a data source without a DSN.
+	ErrNoDSN = errors.New("database dsn is not configured")
+	// ErrUnsupportedDriver is returned for unknown drivers and for helpers not available on a driver.
+	ErrUnsupportedDriver = errors.New("unsupported database driver")
+)
+
+// Open opens the data source described by cfg and configures its connection
+// pool. Statements are logged through NewGormLogger, without their parameters
+// unless cfg.LogParameters is set, and counted by QueryMetrics.
+func Open(cfg DataSourceConfig, opts ...gorm.Option) (*gorm.DB, error) {
+	if cfg.DSN == "" {
+		return nil, ErrNoDSN
+	}
+	dialector, err := DialectorByName(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	level, err := logLevelByName(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	gormConfig := &gorm.Config{
+		Logger: NewGormLogger(gormlogger.Config{
+			SlowThreshold:             cfg.SlowThreshold,
+			LogLevel:                  level,
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      !cfg.LogParameters,
+		}),
+	}
+	db, err := gorm.Open(dialector, append([]gorm.Option{gormConfig}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", cfg.Driver, err)
+	}
+	if err := db.Use(NewQueryMetrics(cfg.SlowThreshold)); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
+
+// DialectorByName returns the GORM dialector of driver for dsn.
+func DialectorByName(driver, dsn string) (gorm.Dialector, error) {
+	switch strings.ToLower(driver) {
+	case DriverPostgres, "postgresql":
+		return postgres.Open(dsn), nil
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	case DriverSQLite, "sqlite3":
+		return sqlite.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedDriver, driver)
+}
+
+// logLevelByName returns the GORM log level for "silent", "error", "warn" or "info".
+func logLevelByName(name string) (gormlogger.LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "silent":
+		return gormlogger.Silent, nil
+	case "error":
+		return gormlogger.Error, nil
+	case "", "warn":
+		return gormlogger.Warn, nil
+	case "info":
+		return gormlogger.Info, nil
+	}
+	return 0, fmt.Errorf("unknown database log level: '%s'", name)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOpenSQLite(t *testing.T) {
+	db, err := Open(DataSourceConfig{
+		Driver:          DriverSQLite,
+		DSN:             "file::memory:",
+		MaxOpenConns:    1,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: time.Minute,
+		LogLevel:        "silent",
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+	var one int
+	require.NoError(t, db.Raw("SELECT 1").Scan(&one).Error)
+	assert.Equal(t, 1, one)
+}
+
+func TestOpenLogsStatementsWithoutParameters(t *testing.T) {
+	for logParameters, want := range map[bool]string{
+		false: "SELECT count(*) FROM `sqlite_master` WHERE name = ?",
+		true:  "SELECT count(*) FROM `sqlite_master` WHERE name = \"secret\"",
+	} {
+		ctx, named := newLoggerContext(t)
+		var got map[string]any
+		named.EXPECT().Debug("Query", gomock.Any()).Do(func(_ string, fields ...logger.Field) {
+			got = fieldMap(fields)
+		})
+
+		db, err := Open(DataSourceConfig{Driver: DriverSQLite, DSN: "file::memory:", LogLevel: "info", LogParameters: logParameters})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sqlDB.Close() })
+
+		var n int64
+		require.NoError(t, db.WithContext(ctx).Table("sqlite_master").Where("name = ?", "secret").Count(&n).Error)
+		assert.Equal(t, want, got["sql"])
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	_, err := Open(DataSourceConfig{Driver: DriverSQLite})
+	assert.ErrorIs(t, err, ErrNoDSN)
+
+	_, err = Open(DataSourceConfig{Driver: "oracle", DSN: "x"})
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+
+	_, err = Open(DataSourceConfig{Driver: DriverSQLite, DSN: "file::memory:", LogLevel: "loud"})
+	assert.ErrorContains(t, err, "unknown database log level")
+}
+
+func TestDialectorByName(t *testing.T) {
+	for driver, want := range map[string]string{
+		"postgres":   DriverPostgres,
+		"PostgreSQL": DriverPostgres,
+		"mysql":      DriverMySQL,
+		"sqlite":     DriverSQLite,
+		"sqlite3":    DriverSQLite,
+	} {
+		dialector, err := DialectorByName(driver, "dsn")
+		require.NoError(t, err, driver)
+		assert.Equal(t, want, dialector.Name(), driver)
+	}
+}
+
+func TestCreateEnumTypesErrors(t *testing.T) {
+	err := CreateEnumTypes(openTestDB(t), "CREATE TYPE mood AS ENUM ('happy')")
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+
+	_, err = getTypeNameFromCreateStmt("CREATE TABLE mood (id int)")
+	assert.Error(t, err)
+
+	name, err := getTypeNameFromCreateStmt(`CREATE TYPE "mood" AS ENUM ('happy', 'sad')`)
+	require.NoError(t, err)
+	assert.Equal(t, "mood", name)
+}
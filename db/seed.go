@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"gorm.io/gorm"
+)
+
+// DefaultSeedProfiles are the profiles of seeders that do not name any.
+var DefaultSeedProfiles = []string{"development", "test"}
+
+// Seeder fills the database with data for the given profiles, matched
+// against the application environment. Seeders run on every start in those
+// profiles, so they should skip data that already exists.
+type Seeder struct {
+	Name string
+	// Profiles the seeder runs in; DefaultSeedProfiles when empty.
+	Profiles []string
+	Run      func(ctx context.Context, tx *gorm.DB) error
+}
+
+// SeederSource is implemented by modules contributing seeders.
+type SeederSource interface {
+	Seeders() []Seeder
+}
+
+// FixtureSeeder returns a seeder loading the fixtures of models from dir in
+// fsys; see LoadFixtures. Rows whose primary key or unique columns are already
+// stored are skipped, so fixtures need their keys to be loaded only once.
+func FixtureSeeder(name string, fsys fs.FS, dir string, models ...any) Seeder {
+	return Seeder{
+		Name: name,
+		Run: func(ctx context.Context, tx *gorm.DB) error {
+			return loadFixtures(ctx, tx, fsys, dir, true, models)
+		},
+	}
+}
+
+// RunSeeders runs, in order, the seeders matching profile, each in its own
+// transaction, and returns the names of those that ran.
+func RunSeeders(ctx context.Context, db *gorm.DB, profile string, seeders ...Seeder) ([]string, error) {
+	var ran []string
+	for _, s := range seeders {
+		if !s.runsIn(profile) {
+			continue
+		}
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.Run(ctx, tx)
+		})
+		if err != nil {
+			return ran, fmt.Errorf("seeder %s: %w", s.Name, err)
+		}
+		logger.FromContext(ctx).Info("Seeder applied", logger.String("name", s.Name), logger.String("profile", profile))
+		ran = append(ran, s.Name)
+	}
+	return ran, nil
+}
+
+func (s Seeder) runsIn(profile string) bool {
+	profiles := s.Profiles
+	if len(profiles) == 0 {
+		profiles = DefaultSeedProfiles
+	}
+	for _, p := range profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
@@ -35,6 +35,8 @@ func NewApplication(opts ...Option) Application {
 		module.WithCache(options.Cache),
 		module.WithEventBus(options.EventBus),
 		module.WithDB(options.DB),
+		module.WithDataSources(options.DataSources),
+		module.WithCursorKey(options.CursorKey),
 	)
 
 	app := &application{
@@ -0,0 +1,9 @@
+package entity
+
+// Tenanted scopes an entity to a tenant when embedded. The tenancy plugin
+// stamps TenantID from the context on create and restricts every query to the
+// context's tenant.
+type Tenanted struct {
+	// TenantID is the tenant owning the entity.
+	TenantID string `gorm:"type:varchar(64);not null;index" json:"tenant_id"`
+}
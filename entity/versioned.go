@@ -0,0 +1,25 @@
+package entity
+
+// Versioned enables optimistic locking when embedded in an entity. The
+// repository only updates rows whose version still matches the entity's and
+// increments it on every successful update.
+type Versioned struct {
+	// Version is incremented on every update.
+	Version int64 `gorm:"not null;default:1" json:"version"`
+}
+
+// GetVersion returns the version the entity was loaded with.
+func (v *Versioned) GetVersion() int64 {
+	return v.Version
+}
+
+// SetVersion sets the version.
+func (v *Versioned) SetVersion(version int64) {
+	v.Version = version
+}
+
+// Versionable is implemented by entities embedding Versioned.
+type Versionable interface {
+	GetVersion() int64
+	SetVersion(version int64)
+}
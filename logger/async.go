@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrWriterClosed is returned when writing to a closed AsyncWriter.
+var ErrWriterClosed = errors.New("logger: async writer closed")
+
+// WriteSyncer is an io.Writer that can flush buffered data.
+type WriteSyncer interface {
+	io.Writer
+	Sync() error
+}
+
+// blockingWriter is implemented by writers that can guarantee delivery of an
+// entry, used for panic and fatal entries that must not be dropped.
+type blockingWriter interface {
+	WriteBlocking(p []byte) (int, error)
+}
+
+// asyncMessage is either an entry to write or a flush request.
+type asyncMessage struct {
+	data  []byte
+	flush chan error
+}
+
+// AsyncWriter writes to an underlying writer from a background goroutine
+// through a bounded queue. When the queue is full, entries are dropped and
+// counted instead of blocking the caller.
+type AsyncWriter struct {
+	out       io.Writer
+	queue     chan asyncMessage
+	dropped   atomic.Uint64
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+// NewAsyncWriter starts an AsyncWriter in front of w with room for size queued entries.
+func NewAsyncWriter(w io.Writer, size int) *AsyncWriter {
+	if size <= 0 {
+		size = 1
+	}
+	aw := &AsyncWriter{
+		out:    w,
+		queue:  make(chan asyncMessage, size),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go aw.run()
+	return aw
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+	for {
+		select {
+		case msg := <-aw.queue:
+			aw.handle(msg)
+		case <-aw.closed:
+			// Drain what is left before stopping.
+			for {
+				select {
+				case msg := <-aw.queue:
+					aw.handle(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (aw *AsyncWriter) handle(msg asyncMessage) {
+	if msg.flush != nil {
+		msg.flush <- syncWriter(aw.out)
+		return
+	}
+	_, _ = aw.out.Write(msg.data)
+}
+
+// Write queues a copy of p. It never blocks; when the queue is full the entry is dropped.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	select {
+	case <-aw.closed:
+		return 0, ErrWriterClosed
+	default:
+	}
+	data := append([]byte(nil), p...)
+	select {
+	case aw.queue <- asyncMessage{data: data}:
+	default:
+		aw.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// WriteBlocking queues a copy of p, waiting for room in the queue.
+func (aw *AsyncWriter) WriteBlocking(p []byte) (int, error) {
+	data := append([]byte(nil), p...)
+	select {
+	case aw.queue <- asyncMessage{data: data}:
+		return len(p), nil
+	case <-aw.closed:
+		return 0, ErrWriterClosed
+	}
+}
+
+// Dropped returns the number of entries dropped because the queue was full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
+// Sync waits until every entry queued before the call is written and then
+// syncs the underlying writer when it supports it.
+func (aw *AsyncWriter) Sync() error {
+	flush := make(chan error, 1)
+	select {
+	case aw.queue <- asyncMessage{flush: flush}:
+	case <-aw.closed:
+		<-aw.done
+		return syncWriter(aw.out)
+	}
+	select {
+	case err := <-flush:
+		return err
+	case <-aw.done:
+		return syncWriter(aw.out)
+	}
+}
+
+// Close flushes the queue and stops the background goroutine. The underlying writer is not closed.
+func (aw *AsyncWriter) Close() error {
+	aw.closeOnce.Do(func() { close(aw.closed) })
+	<-aw.done
+	return syncWriter(aw.out)
+}
+
+// syncWriter syncs w when it is a WriteSyncer. Errors from syncing the
+// standard streams are ignored, as terminals and pipes do not support fsync.
+func syncWriter(w io.Writer) error {
+	ws, ok := w.(WriteSyncer)
+	if !ok || isStdStream(w) {
+		return nil
+	}
+	return ws.Sync()
+}
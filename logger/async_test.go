@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingBuffer is a writer whose writes wait until it is released.
+type blockingBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+	syncs   int
+}
+
+func (b *blockingBuffer) Write(p []byte) (int, error) {
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *blockingBuffer) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.syncs++
+	return nil
+}
+
+func (b *blockingBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncWriterSyncFlushes(t *testing.T) {
+	out := &blockingBuffer{release: make(chan struct{})}
+	close(out.release)
+	w := NewAsyncWriter(out, 16)
+	defer w.Close()
+
+	_, _ = w.Write([]byte("a\n"))
+	_, _ = w.Write([]byte("b\n"))
+
+	assert.NoError(t, w.Sync())
+	assert.Equal(t, "a\nb\n", out.String())
+	assert.Equal(t, 1, out.syncs)
+}
+
+func TestAsyncWriterDropsWhenFull(t *testing.T) {
+	out := &blockingBuffer{release: make(chan struct{})}
+	w := NewAsyncWriter(out, 2)
+
+	// The first entry may be taken by the background goroutine and block there;
+	// at most two more fit in the queue.
+	for i := 0; i < 10; i++ {
+		_, _ = w.Write([]byte("x"))
+	}
+	assert.GreaterOrEqual(t, w.Dropped(), uint64(7))
+
+	close(out.release)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 10-int(w.Dropped()), len(out.String()))
+
+	_, err := w.Write([]byte("late"))
+	assert.ErrorIs(t, err, ErrWriterClosed)
+}
+
+func TestDefaultLoggerPanicFlushesAsyncOutput(t *testing.T) {
+	out := &blockingBuffer{release: make(chan struct{})}
+	close(out.release)
+	w := NewAsyncWriter(out, 16)
+	defer w.Close()
+	provider := NewDefaultLogger("production", WithOutput(w))
+
+	assert.Panics(t, func() { New(provider).Panic("boom") })
+	assert.Contains(t, out.String(), `"message":"boom"`)
+}
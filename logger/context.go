@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey struct{}
+
+var (
+	fallbackOnce   sync.Once
+	fallbackLogger Logger
+)
+
+// IntoContext returns a copy of ctx that carries l.
+func IntoContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx. When ctx carries none it
+// returns DefaultLogger, or a development logger if DefaultLogger is not set.
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+			return l
+		}
+	}
+	if DefaultLogger != nil {
+		return DefaultLogger
+	}
+	fallbackOnce.Do(func() {
+		fallbackLogger = New(NewDefaultLogger("development"))
+	})
+	return fallbackLogger
+}
@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	provider := &recordingProvider{}
+	log := New(provider).With(RequestIDField("req-1"))
+
+	ctx := IntoContext(context.Background(), log)
+
+	assert.Same(t, log, FromContext(ctx))
+}
+
+func TestFromContextFallback(t *testing.T) {
+	previous := DefaultLogger
+	defer func() { DefaultLogger = previous }()
+
+	DefaultLogger = nil
+	assert.NotNil(t, FromContext(context.Background()))
+
+	DefaultLogger = New(&recordingProvider{})
+	assert.Same(t, DefaultLogger, FromContext(context.Background()))
+}
@@ -1,38 +1,71 @@
 package logger
 
 import (
-	"encoding/json"
-	"log"
+	"bytes"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // DefaultLogger is a custom logger that mimics LogrusProvider's methods.
+// It writes every entry it receives; level filtering is done by Logger.
 type defaultLogger struct {
 	defaultFields map[string]any
-	infoLogger    *log.Logger
-	errorLogger   *log.Logger
-	debugLogger   *log.Logger
+	encoder       Encoder
+	out           io.Writer // trace to warn
+	errOut        io.Writer // error and above
+	mu            *sync.Mutex
 }
 
-// NewDefaultLogger creates a new DefaultLogger.
-func NewDefaultLogger(mode string) *defaultLogger {
-	infoLogger := log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	errorLogger := log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	debugLogger := log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+// DefaultOption configures the default provider.
+type DefaultOption func(*defaultLogger)
 
+// WithEncoder sets the encoder used to format entries.
+func WithEncoder(encoder Encoder) DefaultOption {
+	return func(l *defaultLogger) {
+		l.encoder = encoder
+	}
+}
+
+// WithOutput sets the writer for entries below the error level, and for all
+// entries unless WithErrorOutput is given as well.
+func WithOutput(w io.Writer) DefaultOption {
+	return func(l *defaultLogger) {
+		l.out = w
+		l.errOut = w
+	}
+}
+
+// WithErrorOutput sets the writer for error, panic and fatal entries.
+func WithErrorOutput(w io.Writer) DefaultOption {
+	return func(l *defaultLogger) {
+		l.errOut = w
+	}
+}
+
+// NewDefaultLogger creates a new DefaultLogger. In production it writes JSON,
+// otherwise colored console output; both can be overridden with options.
+func NewDefaultLogger(mode string, opts ...DefaultOption) *defaultLogger {
 	logger := &defaultLogger{
 		defaultFields: make(map[string]any),
-		infoLogger:    infoLogger,
-		errorLogger:   errorLogger,
-		debugLogger:   nil,
+		out:           os.Stdout,
+		errOut:        os.Stderr,
+		mu:            &sync.Mutex{},
 	}
-
-	// Enable debug logs only in non-production mode
-	if mode != "production" {
-		logger.debugLogger = debugLogger
-		logger.Info("Logger initialized in debug mode", map[string]any{"mode": mode})
+	if mode == "production" {
+		logger.encoder = NewJSONEncoder()
 	} else {
-		logger.debugLogger = log.New(nil, "", 0) // Disable debug logs
+		logger.encoder = NewConsoleEncoder(true)
+	}
+
+	for _, o := range opts {
+		o(logger)
 	}
 
 	return logger
@@ -57,62 +90,139 @@ func mergeFields(defaultFields, fields map[string]any) map[string]any {
 	return merged
 }
 
-// formatFields converts fields to a readable JSON string.
-func formatFields(fields map[string]any) string {
-	jsonData, err := json.MarshalIndent(fields, "", "  ")
-	if err != nil {
-		return "{\"error\": \"failed to format fields\"}"
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// write encodes the entry and writes it to the output matching its level.
+func (l *defaultLogger) write(level Level, msg string, fields map[string]any) {
+	entry := &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Caller:  callerOutsideLogger(),
+		Fields:  mergeFields(l.defaultFields, fields),
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	if err := l.encoder.Encode(buf, entry); err != nil {
+		buf.Reset()
+		buf.WriteString("logger: failed to encode entry: " + err.Error() + "\n")
+	}
+
+	out := l.out
+	if level >= ErrorLevel {
+		out = l.errOut
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Entries that end the process must not be dropped by buffering writers.
+	if bw, ok := out.(blockingWriter); ok && level >= DPanicLevel {
+		_, _ = bw.WriteBlocking(buf.Bytes())
+		return
+	}
+	_, _ = out.Write(buf.Bytes())
+}
+
+// isStdStream reports whether w is the process's standard output or error.
+func isStdStream(w io.Writer) bool {
+	return w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr)
+}
+
+// callerSkipPrefixes lists the packages whose frames are skipped when looking up the caller.
+var callerSkipPrefixes = []string{"github.com/ebrickdev/ebrick/logger.", "github.com/ebrickdev/ebrick/logger/", "log/slog."}
+
+// callerOutsideLogger returns "dir/file.go:line" of the first frame outside the logging packages.
+func callerOutsideLogger() string {
+	frame, ok := CallerFrame()
+	if !ok {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(frame.File)) + "/" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+}
+
+// CallerFrame returns the first frame of the stack outside the logger package
+// and its subpackages, the code that logged. Providers use it to report the
+// caller however the logger is wrapped.
+func CallerFrame() (runtime.Frame, bool) {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !skipFrame(frame) {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+func skipFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	for _, prefix := range callerSkipPrefixes {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return true
+		}
 	}
-	return string(jsonData)
+	return false
+}
+
+// Trace logs a trace message.
+func (l *defaultLogger) Trace(msg string, fields map[string]any) {
+	l.write(TraceLevel, msg, fields)
 }
 
 // Debug logs a debug message.
 func (l *defaultLogger) Debug(msg string, fields map[string]any) {
-	if l.debugLogger != nil {
-		allFields := mergeFields(l.defaultFields, fields)
-		l.debugLogger.Printf("%s | %s", msg, formatFields(allFields))
-	}
+	l.write(DebugLevel, msg, fields)
 }
 
 // Info logs an informational message.
 func (l *defaultLogger) Info(msg string, fields map[string]any) {
-	allFields := mergeFields(l.defaultFields, fields)
-	l.infoLogger.Printf("%s | %s", msg, formatFields(allFields))
+	l.write(InfoLevel, msg, fields)
 }
 
 // Warn logs a warning message.
 func (l *defaultLogger) Warn(msg string, fields map[string]any) {
-	allFields := mergeFields(l.defaultFields, fields)
-	l.infoLogger.Printf("WARN: %s | %s", msg, formatFields(allFields))
+	l.write(WarnLevel, msg, fields)
 }
 
 // Error logs an error message.
 func (l *defaultLogger) Error(msg string, fields map[string]any) {
-	allFields := mergeFields(l.defaultFields, fields)
-	l.errorLogger.Printf("%s | %s", msg, formatFields(allFields))
+	l.write(ErrorLevel, msg, fields)
 }
 
-// DPanic logs a debug panic message and panics.
+// DPanic logs a debug panic message, flushes and panics.
 func (l *defaultLogger) DPanic(msg string, fields map[string]any) {
-	l.Error(msg, fields)
+	l.write(DPanicLevel, msg, fields)
+	_ = l.Sync()
 	panic(msg)
 }
 
-// Panic logs a panic message and panics.
+// Panic logs a panic message, flushes and panics.
 func (l *defaultLogger) Panic(msg string, fields map[string]any) {
-	l.Error(msg, fields)
+	l.write(PanicLevel, msg, fields)
+	_ = l.Sync()
 	panic(msg)
 }
 
-// Fatal logs a fatal message and exits.
+// Fatal logs a fatal message, flushes and exits.
 func (l *defaultLogger) Fatal(msg string, fields map[string]any) {
-	allFields := mergeFields(l.defaultFields, fields)
-	l.errorLogger.Printf("FATAL: %s | %s", msg, formatFields(allFields))
+	l.write(FatalLevel, msg, fields)
+	_ = l.Sync()
 	os.Exit(1)
 }
 
-// Sync is a no-op for DefaultLogger (similar to logrus behavior).
+// Sync flushes the outputs that buffer entries, such as an AsyncWriter.
 func (l *defaultLogger) Sync() error {
-	// Standard log doesn't need synchronization.
-	return nil
+	err := syncWriter(l.out)
+	if l.errOut != l.out {
+		err = errors.Join(err, syncWriter(l.errOut))
+	}
+	return err
 }
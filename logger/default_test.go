@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultLoggerWritesSingleLineJSON(t *testing.T) {
+	var out, errOut bytes.Buffer
+	provider := NewDefaultLogger("production", WithOutput(&out), WithErrorOutput(&errOut))
+
+	New(provider).Info("order created", String("id", "42"))
+	New(provider).Error("order failed")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 1)
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "info", entry[LevelKey])
+	assert.Equal(t, "order created", entry[MessageKey])
+	assert.Equal(t, "42", entry["id"])
+	assert.True(t, strings.HasPrefix(entry[CallerKey].(string), "logger/default_test.go:"), entry[CallerKey])
+	assert.Contains(t, errOut.String(), `"message":"order failed"`)
+}
+
+func TestDefaultLoggerWithEncoder(t *testing.T) {
+	var out bytes.Buffer
+	provider := NewDefaultLogger("production", WithOutput(&out), WithEncoder(NewLogfmtEncoder()))
+
+	provider.Warn("slow", map[string]any{"ms": 1200})
+
+	assert.Contains(t, out.String(), "level=warn")
+	assert.Contains(t, out.String(), "message=slow ms=1200\n")
+}
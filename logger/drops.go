@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDropReportInterval is how often dropped entries are reported.
+const DefaultDropReportInterval = time.Minute
+
+// DropCounter counts entries dropped on their way to the output, such as an
+// AsyncWriter with a full queue or a SampledProvider.
+type DropCounter interface {
+	Dropped() uint64
+}
+
+// ReportDropped warns on l, every interval, about the entries each named
+// counter dropped since the previous report, so that drops do not go
+// unnoticed. The returned function reports a last time and stops; call it
+// before syncing the logger on shutdown.
+func ReportDropped(l Logger, interval time.Duration, counters map[string]DropCounter) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultDropReportInterval
+	}
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mu sync.Mutex
+	reported := make(map[string]uint64, len(counters))
+	report := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, name := range names {
+			total := counters[name].Dropped()
+			if n := total - reported[name]; n > 0 {
+				reported[name] = total
+				l.Warn("Log entries dropped", String("source", name), Any("dropped", n), Any("total", total))
+			}
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				report()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			report()
+		})
+	}
+}
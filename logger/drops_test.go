@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCounter struct{ n atomic.Uint64 }
+
+func (c *fakeCounter) Dropped() uint64 { return c.n.Load() }
+
+func TestReportDropped(t *testing.T) {
+	provider := &recordingProvider{}
+	queue, sampling := &fakeCounter{}, &fakeCounter{}
+	queue.n.Store(3)
+
+	stop := ReportDropped(New(provider), time.Hour, map[string]DropCounter{"queue": queue, "sampling": sampling})
+	stop()
+	stop()
+
+	assert.Equal(t, []string{"Log entries dropped"}, provider.messages())
+	assert.Equal(t, map[string]any{"source": "queue", "dropped": uint64(3), "total": uint64(3)}, provider.entries[0].fields)
+}
+
+// syncProvider is a recordingProvider safe for concurrent use.
+type syncProvider struct {
+	recordingProvider
+	mu sync.Mutex
+}
+
+func (p *syncProvider) Warn(msg string, fields map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recordingProvider.Warn(msg, fields)
+}
+
+func (p *syncProvider) snapshot() []recordedEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]recordedEntry(nil), p.entries...)
+}
+
+func TestReportDroppedPeriodically(t *testing.T) {
+	provider := &syncProvider{}
+	counter := &fakeCounter{}
+	counter.n.Store(2)
+
+	stop := ReportDropped(New(provider), 10*time.Millisecond, map[string]DropCounter{"queue": counter})
+	assert.Eventually(t, func() bool { return len(provider.snapshot()) == 1 }, time.Second, 5*time.Millisecond)
+	counter.n.Store(5)
+	stop()
+
+	entries := provider.snapshot()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, uint64(3), entries[1].fields["dropped"])
+}
@@ -0,0 +1,320 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Keys of the standard entry attributes written by the encoders.
+const (
+	TimestampKey = "timestamp"
+	LevelKey     = "level"
+	CallerKey    = "caller"
+	MessageKey   = "message"
+)
+
+// ChainSuffix is appended to the key of an error field to hold its wrapped chain.
+const ChainSuffix = "_chain"
+
+// TimeFormat is the timestamp layout used by the encoders.
+const TimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Entry is a single log entry handed to an Encoder.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Caller  string
+	Fields  map[string]any
+}
+
+// Encoder serializes an Entry, including the trailing newline, into buf.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, e *Entry) error
+}
+
+// EncoderByName returns the encoder for "json", "logfmt" or "console".
+func EncoderByName(name string) (Encoder, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return NewJSONEncoder(), nil
+	case "logfmt":
+		return NewLogfmtEncoder(), nil
+	case "console":
+		return NewConsoleEncoder(true), nil
+	}
+	return nil, fmt.Errorf("unknown log format: '%s'", name)
+}
+
+// jsonEncoder writes one JSON object per line.
+type jsonEncoder struct{}
+
+// NewJSONEncoder returns an encoder that writes single-line JSON objects.
+func NewJSONEncoder() Encoder {
+	return jsonEncoder{}
+}
+
+func (jsonEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	buf.WriteByte('{')
+	writeJSONPair(buf, TimestampKey, e.Time.Format(TimeFormat), true)
+	writeJSONPair(buf, LevelKey, e.Level.String(), false)
+	if e.Caller != "" {
+		writeJSONPair(buf, CallerKey, e.Caller, false)
+	}
+	writeJSONPair(buf, MessageKey, e.Message, false)
+	fields := expandErrors(e.Fields)
+	for _, k := range sortedKeys(fields) {
+		writeJSONPair(buf, k, fields[k], false)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+func writeJSONPair(buf *bytes.Buffer, key string, value any, first bool) {
+	if !first {
+		buf.WriteByte(',')
+	}
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprintf("%+v", value))
+	}
+	buf.Write(v)
+}
+
+// logfmtEncoder writes key=value pairs.
+type logfmtEncoder struct{}
+
+// NewLogfmtEncoder returns an encoder that writes logfmt lines.
+func NewLogfmtEncoder() Encoder {
+	return logfmtEncoder{}
+}
+
+func (logfmtEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	writeLogfmtPair(buf, TimestampKey, e.Time.Format(TimeFormat))
+	buf.WriteByte(' ')
+	writeLogfmtPair(buf, LevelKey, e.Level.String())
+	if e.Caller != "" {
+		buf.WriteByte(' ')
+		writeLogfmtPair(buf, CallerKey, e.Caller)
+	}
+	buf.WriteByte(' ')
+	writeLogfmtPair(buf, MessageKey, e.Message)
+	fields := expandErrors(e.Fields)
+	for _, k := range sortedKeys(fields) {
+		buf.WriteByte(' ')
+		writeLogfmtPair(buf, k, fields[k])
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key string, value any) {
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	buf.WriteString(logfmtValue(formatValue(value)))
+}
+
+// logfmtKey replaces characters that are not allowed in logfmt keys.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue quotes values that contain spaces, quotes, '=' or control characters.
+func logfmtValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// ANSI color codes used by the console encoder.
+const (
+	colorReset  = "\x1b[0m"
+	colorGray   = "\x1b[90m"
+	colorCyan   = "\x1b[36m"
+	colorBlue   = "\x1b[34m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+	colorBold   = "\x1b[1m"
+)
+
+// consoleEncoder writes a human readable line for development.
+type consoleEncoder struct {
+	color bool
+}
+
+// NewConsoleEncoder returns a human readable encoder for development, with ANSI colors if color is set.
+func NewConsoleEncoder(color bool) Encoder {
+	return consoleEncoder{color: color}
+}
+
+func (c consoleEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	buf.WriteString(c.paint(colorGray, e.Time.Format(TimeFormat)))
+	buf.WriteByte(' ')
+	buf.WriteString(c.paint(levelColor(e.Level), fmt.Sprintf("%-5s", strings.ToUpper(e.Level.String()))))
+	buf.WriteByte(' ')
+	if e.Caller != "" {
+		buf.WriteString(c.paint(colorGray, e.Caller))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(e.Message)
+
+	fields := e.Fields
+	var errs []string
+	for _, k := range sortedKeys(fields) {
+		if err, ok := fields[k].(error); ok && err != nil {
+			errs = append(errs, k)
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(c.paint(colorCyan, logfmtKey(k)+"="))
+		buf.WriteString(logfmtValue(formatValue(fields[k])))
+	}
+	buf.WriteByte('\n')
+
+	// Render errors last with one line per wrapped cause.
+	for _, k := range errs {
+		chain := errorChain(fields[k].(error))
+		buf.WriteString("    ")
+		buf.WriteString(c.paint(colorRed, k+": "))
+		buf.WriteString(chain[0])
+		buf.WriteByte('\n')
+		for _, cause := range chain[1:] {
+			buf.WriteString("        caused by: ")
+			buf.WriteString(cause)
+			buf.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+func (c consoleEncoder) paint(color, s string) string {
+	if !c.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func levelColor(l Level) string {
+	switch {
+	case l <= TraceLevel:
+		return colorGray
+	case l == DebugLevel:
+		return colorBlue
+	case l == InfoLevel:
+		return colorCyan
+	case l == WarnLevel:
+		return colorYellow
+	case l == ErrorLevel:
+		return colorRed
+	}
+	return colorBold + colorRed
+}
+
+// expandErrors replaces error values by their message and adds the wrapped
+// chain under key+ChainSuffix when the error wraps other errors.
+func expandErrors(fields map[string]any) map[string]any {
+	var expanded map[string]any
+	for k, v := range fields {
+		err, ok := v.(error)
+		if !ok {
+			continue
+		}
+		if expanded == nil {
+			expanded = make(map[string]any, len(fields)+1)
+			for k2, v2 := range fields {
+				expanded[k2] = v2
+			}
+		}
+		if err == nil {
+			expanded[k] = nil
+			continue
+		}
+		chain := errorChain(err)
+		expanded[k] = chain[0]
+		if len(chain) > 1 {
+			expanded[k+ChainSuffix] = chain[1:]
+		}
+	}
+	if expanded == nil {
+		return fields
+	}
+	return expanded
+}
+
+// errorChain returns the messages of err and of every error it wraps, depth first.
+// Each wrapped error is prefixed with its type.
+func errorChain(err error) []string {
+	chain := []string{err.Error()}
+	var walk func(e error)
+	walk = func(e error) {
+		var next []error
+		switch u := e.(type) {
+		case interface{ Unwrap() []error }:
+			next = u.Unwrap()
+		case interface{ Unwrap() error }:
+			if w := u.Unwrap(); w != nil {
+				next = []error{w}
+			}
+		}
+		for _, w := range next {
+			if w == nil {
+				continue
+			}
+			chain = append(chain, fmt.Sprintf("%T: %s", w, w.Error()))
+			walk(w)
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// formatValue renders a field value as text for the line-oriented encoders.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return val
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	case []string:
+		return strings.Join(val, " | ")
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(val)
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+func sortedKeys(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testEntry() *Entry {
+	cause := errors.New("connection refused")
+	return &Entry{
+		Time:    time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Level:   WarnLevel,
+		Message: "retrying request",
+		Caller:  "orders/service.go:42",
+		Fields: map[string]any{
+			"attempt": 3,
+			"path":    "/orders list",
+			"error":   fmt.Errorf("dial db: %w", cause),
+		},
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewJSONEncoder().Encode(&buf, testEntry())
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"timestamp":"2024-05-01T12:30:00.000Z","level":"warn","caller":"orders/service.go:42",`+
+		`"message":"retrying request","attempt":3,"error":"dial db: connection refused",`+
+		`"error_chain":["*errors.errorString: connection refused"],"path":"/orders list"}`+"\n", buf.String())
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewLogfmtEncoder().Encode(&buf, testEntry())
+
+	assert.NoError(t, err)
+	assert.Equal(t, `timestamp=2024-05-01T12:30:00.000Z level=warn caller=orders/service.go:42 `+
+		`message="retrying request" attempt=3 error="dial db: connection refused" `+
+		`error_chain="*errors.errorString: connection refused" path="/orders list"`+"\n", buf.String())
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewConsoleEncoder(false).Encode(&buf, testEntry())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-01T12:30:00.000Z WARN  orders/service.go:42 retrying request attempt=3 path=\"/orders list\"\n"+
+		"    error: dial db: connection refused\n"+
+		"        caused by: *errors.errorString: connection refused\n", buf.String())
+
+	// Levels longer than the padding stay apart from the caller.
+	buf.Reset()
+	entry := &Entry{Time: testEntry().Time, Level: DPanicLevel, Message: "invariant broken", Caller: "orders/service.go:42"}
+	assert.NoError(t, NewConsoleEncoder(false).Encode(&buf, entry))
+	assert.Equal(t, "2024-05-01T12:30:00.000Z DPANIC orders/service.go:42 invariant broken\n", buf.String())
+}
+
+func TestEncoderByName(t *testing.T) {
+	for _, name := range []string{"json", "logfmt", "console", "JSON"} {
+		encoder, err := EncoderByName(name)
+		assert.NoError(t, err)
+		assert.NotNil(t, encoder)
+	}
+	_, err := EncoderByName("xml")
+	assert.Error(t, err)
+}
+
+func TestErrorChainJoined(t *testing.T) {
+	err := errors.Join(errors.New("a"), fmt.Errorf("b: %w", errors.New("c")))
+
+	chain := errorChain(err)
+
+	assert.Equal(t, []string{
+		"a\nb: c",
+		"*errors.errorString: a",
+		"*fmt.wrapError: b: c",
+		"*errors.errorString: c",
+	}, chain)
+}
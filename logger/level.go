@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Level int8
@@ -18,6 +19,10 @@ const (
 	WarnLevel
 	// ErrorLevel level. Logs. Used for errors that should definitely be noted.
 	ErrorLevel
+	// DPanicLevel level. Logs critical errors that panic in development.
+	DPanicLevel
+	// PanicLevel level. Logs and then panics.
+	PanicLevel
 	// FatalLevel level. Logs and then calls `logger.Exit(1)`. highest level of severity.
 	FatalLevel
 )
@@ -34,6 +39,10 @@ func (l Level) String() string {
 		return "warn"
 	case ErrorLevel:
 		return "error"
+	case DPanicLevel:
+		return "dpanic"
+	case PanicLevel:
+		return "panic"
 	case FatalLevel:
 		return "fatal"
 	}
@@ -48,7 +57,7 @@ func (l Level) Enabled(lvl Level) bool {
 // GetLevel converts a level string into a logger Level value.
 // returns an error if the input string does not match known values.
 func GetLevel(levelStr string) (Level, error) {
-	switch levelStr {
+	switch strings.ToLower(strings.TrimSpace(levelStr)) {
 	case TraceLevel.String():
 		return TraceLevel, nil
 	case DebugLevel.String():
@@ -59,8 +68,36 @@ func GetLevel(levelStr string) (Level, error) {
 		return WarnLevel, nil
 	case ErrorLevel.String():
 		return ErrorLevel, nil
+	case DPanicLevel.String():
+		return DPanicLevel, nil
+	case PanicLevel.String():
+		return PanicLevel, nil
 	case FatalLevel.String():
 		return FatalLevel, nil
 	}
 	return InfoLevel, fmt.Errorf("unknown level string: '%s', defaulting to InfoLevel", levelStr)
 }
+
+// MarshalText implements encoding.TextMarshaler.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *Level) UnmarshalText(text []byte) error {
+	lvl, err := GetLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lvl
+	return nil
+}
+
+// DefaultLevel returns the initial level for an application environment:
+// info in production and debug everywhere else.
+func DefaultLevel(env string) Level {
+	if env == "production" {
+		return InfoLevel
+	}
+	return DebugLevel
+}
@@ -36,6 +36,18 @@ func TestGetLevel(t *testing.T) {
 			want:    ErrorLevel,
 			wantErr: false,
 		},
+		{
+			name:    "Valid level TRACE",
+			args:    args{levelStr: "trace"},
+			want:    TraceLevel,
+			wantErr: false,
+		},
+		{
+			name:    "Valid level PANIC mixed case",
+			args:    args{levelStr: "Panic"},
+			want:    PanicLevel,
+			wantErr: false,
+		},
 		{
 			name:    "Invalid level",
 			args:    args{levelStr: "invalid"},
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultLevels holds the levels of the application logger tree. It is set
+// together with DefaultLogger and served by the log level admin endpoint.
+var DefaultLevels *Levels
+
+// Levels holds the minimum level of a logger tree together with per-name overrides.
+// Overrides are hierarchical: an override for "module" also applies to "module.orders"
+// unless a more specific override exists. Levels is safe for concurrent use and can
+// be changed at runtime.
+type Levels struct {
+	mu        sync.Mutex // serializes writers
+	root      atomic.Int32
+	overrides atomic.Pointer[map[string]Level]
+}
+
+// NewLevels creates a Levels with the given root level and no overrides.
+func NewLevels(root Level) *Levels {
+	lv := &Levels{}
+	lv.root.Store(int32(root))
+	lv.overrides.Store(&map[string]Level{})
+	return lv
+}
+
+// ParseLevels builds Levels from a spec such as "info,module.orders=debug,gorm=warn".
+// A bare level sets the root level; name=level pairs set overrides.
+func ParseLevels(spec string) (*Levels, error) {
+	lv := NewLevels(InfoLevel)
+	if err := lv.Apply(spec); err != nil {
+		return nil, err
+	}
+	return lv, nil
+}
+
+// Apply applies a level spec (see ParseLevels) on top of the current levels.
+func (lv *Levels) Apply(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, levelStr, named := strings.Cut(part, "=")
+		if !named {
+			levelStr = name
+		}
+		level, err := GetLevel(levelStr)
+		if err != nil {
+			return err
+		}
+		if named {
+			lv.SetNamed(strings.TrimSpace(name), level)
+		} else {
+			lv.SetLevel(level)
+		}
+	}
+	return nil
+}
+
+// Level returns the root level.
+func (lv *Levels) Level() Level {
+	return Level(lv.root.Load())
+}
+
+// SetLevel changes the root level.
+func (lv *Levels) SetLevel(level Level) {
+	lv.root.Store(int32(level))
+}
+
+// SetNamed sets the level override of the named logger.
+func (lv *Levels) SetNamed(name string, level Level) {
+	if name == "" {
+		lv.SetLevel(level)
+		return
+	}
+	lv.update(func(m map[string]Level) { m[name] = level })
+}
+
+// ResetNamed removes the level override of the named logger.
+func (lv *Levels) ResetNamed(name string) {
+	lv.update(func(m map[string]Level) { delete(m, name) })
+}
+
+// Overrides returns a copy of the per-name overrides.
+func (lv *Levels) Overrides() map[string]Level {
+	current := *lv.overrides.Load()
+	copied := make(map[string]Level, len(current))
+	for k, v := range current {
+		copied[k] = v
+	}
+	return copied
+}
+
+// LevelFor returns the effective level of the named logger.
+func (lv *Levels) LevelFor(name string) Level {
+	overrides := *lv.overrides.Load()
+	for name != "" && len(overrides) > 0 {
+		if level, ok := overrides[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return lv.Level()
+}
+
+// Enabled reports whether the named logger logs at the given level.
+func (lv *Levels) Enabled(name string, level Level) bool {
+	return lv.LevelFor(name).Enabled(level)
+}
+
+func (lv *Levels) update(fn func(m map[string]Level)) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	next := lv.Overrides()
+	fn(next)
+	lv.overrides.Store(&next)
+}
+
+// levelsPayload is the JSON representation used by the admin endpoint.
+type levelsPayload struct {
+	Level   Level            `json:"level"`
+	Loggers map[string]Level `json:"loggers,omitempty"`
+}
+
+// levelRequest is the body accepted by the admin endpoint.
+// An empty Level with a Logger removes that logger's override.
+type levelRequest struct {
+	Logger string `json:"logger,omitempty"`
+	Level  string `json:"level"`
+}
+
+// ServeHTTP exposes the levels as an admin endpoint.
+//
+//	GET         returns {"level":"info","loggers":{"module.orders":"debug"}}
+//	PUT / POST  accepts {"level":"debug"} or {"logger":"module.orders","level":"debug"}
+func (lv *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeLevelsError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		if req.Logger != "" && req.Level == "" {
+			lv.ResetNamed(req.Logger)
+			break
+		}
+		level, err := GetLevel(req.Level)
+		if err != nil {
+			writeLevelsError(w, http.StatusBadRequest, err)
+			return
+		}
+		lv.SetNamed(req.Logger, level)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelsError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(levelsPayload{Level: lv.Level(), Loggers: lv.Overrides()})
+}
+
+func writeLevelsError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels("warn, module.orders=debug,module=error")
+
+	assert.NoError(t, err)
+	assert.Equal(t, WarnLevel, levels.Level())
+	assert.Equal(t, DebugLevel, levels.LevelFor("module.orders"))
+	assert.Equal(t, DebugLevel, levels.LevelFor("module.orders.repository"))
+	assert.Equal(t, ErrorLevel, levels.LevelFor("module.billing"))
+	assert.Equal(t, WarnLevel, levels.LevelFor("http"))
+	assert.Equal(t, WarnLevel, levels.LevelFor(""))
+}
+
+func TestParseLevelsInvalid(t *testing.T) {
+	_, err := ParseLevels("info,module.orders=loud")
+	assert.Error(t, err)
+}
+
+func TestLevelsResetNamed(t *testing.T) {
+	levels := NewLevels(InfoLevel)
+	levels.SetNamed("gorm", TraceLevel)
+	assert.True(t, levels.Enabled("gorm", TraceLevel))
+
+	levels.ResetNamed("gorm")
+	assert.False(t, levels.Enabled("gorm", TraceLevel))
+	assert.Empty(t, levels.Overrides())
+}
+
+func TestLevelsServeHTTP(t *testing.T) {
+	levels := NewLevels(InfoLevel)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "get", method: http.MethodGet, wantStatus: http.StatusOK, wantBody: `{"level":"info"}`},
+		{name: "set root", method: http.MethodPut, body: `{"level":"debug"}`, wantStatus: http.StatusOK, wantBody: `{"level":"debug"}`},
+		{name: "set named", method: http.MethodPost, body: `{"logger":"module.orders","level":"trace"}`, wantStatus: http.StatusOK, wantBody: `{"level":"debug","loggers":{"module.orders":"trace"}}`},
+		{name: "reset named", method: http.MethodPut, body: `{"logger":"module.orders"}`, wantStatus: http.StatusOK, wantBody: `{"level":"debug"}`},
+		{name: "invalid level", method: http.MethodPut, body: `{"level":"loud"}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPut, body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "method not allowed", method: http.MethodDelete, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			levels.ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/log/level", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			} else {
+				var body map[string]string
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.NotEmpty(t, body["error"])
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ package logger
 var DefaultLogger Logger
 
 type Logger interface {
+	// Trace logs messages at the trace level, finer-grained than debug.
+	Trace(msg string, fields ...Field)
 	// Debug logs messages at the debug level, typically used for low-level system information.
 	Debug(msg string, fields ...Field)
 	// Info logs informational messages that highlight the progress of the application.
@@ -23,30 +25,114 @@ type Logger interface {
 	Fatal(msg string, fields ...Field)
 	// Sync flushes any buffered log entries.
 	Sync() error
+	// SetLevel changes the minimum level of this logger at runtime.
+	// For a named logger it sets the override for its name.
+	SetLevel(level Level)
+	// Level returns the effective minimum level of this logger.
+	Level() Level
+	// With returns a child logger that adds the given fields to every entry.
+	With(fields ...Field) Logger
+	// Named returns a child logger whose name is appended to this logger's name
+	// with a dot, e.g. "module" + "orders" = "module.orders".
+	Named(name string) Logger
 }
 
+// NameKey is the field key under which the logger name is passed to the provider.
+const NameKey = "logger"
+
 type logger struct {
 	provider Provider
+	levels   *Levels
+	name     string
+	fields   []Field
+	redactor *Redactor
 }
 
 // NewLogger creates a new instance of Logger.
-func New(provider Provider) Logger {
-	return &logger{provider}
+func New(provider Provider, opts ...Option) Logger {
+	options := newOptions(opts...)
+	return &logger{
+		provider: provider,
+		levels:   options.Levels,
+		name:     options.Name,
+		redactor: options.Redactor,
+	}
+}
+
+// LevelsOf returns the level state of a logger created by New.
+func LevelsOf(l Logger) (*Levels, bool) {
+	if l, ok := l.(*logger); ok {
+		return l.levels, true
+	}
+	return nil, false
+}
+
+// With implements Logger.
+func (l *logger) With(fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	child := *l
+	child.fields = make([]Field, 0, len(l.fields)+len(fields))
+	child.fields = append(append(child.fields, l.fields...), fields...)
+	return &child
+}
+
+// Named implements Logger.
+func (l *logger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
+}
+
+// enabled reports whether messages at the given level pass the level filter.
+func (l *logger) enabled(level Level) bool {
+	return l.levels.Enabled(l.name, level)
+}
+
+// SetLevel implements Logger.
+func (l *logger) SetLevel(level Level) {
+	l.levels.SetNamed(l.name, level)
+}
+
+// Level implements Logger.
+func (l *logger) Level() Level {
+	return l.levels.LevelFor(l.name)
+}
+
+// Trace implements Logger.
+func (l *logger) Trace(msg string, fields ...Field) {
+	if l.enabled(TraceLevel) {
+		l.provider.Trace(msg, l.convertToProviderFields(fields))
+	}
 }
 
 // DPanic implements Logger.
 func (l *logger) DPanic(msg string, fields ...Field) {
-	l.provider.DPanic(msg, l.convertToProviderFields(fields))
+	if l.enabled(DPanicLevel) {
+		l.provider.DPanic(msg, l.convertToProviderFields(fields))
+	}
 }
 
 // Debug implements Logger.
 func (l *logger) Debug(msg string, fields ...Field) {
-	l.provider.Debug(msg, l.convertToProviderFields(fields))
+	if l.enabled(DebugLevel) {
+		l.provider.Debug(msg, l.convertToProviderFields(fields))
+	}
 }
 
 // Error implements Logger.
 func (l *logger) Error(msg string, fields ...Field) {
-	l.provider.Error(msg, l.convertToProviderFields(fields))
+	if l.enabled(ErrorLevel) {
+		l.provider.Error(msg, l.convertToProviderFields(fields))
+	}
 }
 
 // Fatal implements Logger.
@@ -56,11 +142,17 @@ func (l *logger) Fatal(msg string, fields ...Field) {
 
 // Info implements Logger.
 func (l *logger) Info(msg string, fields ...Field) {
-	l.provider.Info(msg, l.convertToProviderFields(fields))
+	if l.enabled(InfoLevel) {
+		l.provider.Info(msg, l.convertToProviderFields(fields))
+	}
 }
 
-// Panic implements Logger.
+// Panic implements Logger. It panics even when the panic level is filtered out.
 func (l *logger) Panic(msg string, fields ...Field) {
+	if !l.enabled(PanicLevel) {
+		_ = l.provider.Sync()
+		panic(msg)
+	}
 	l.provider.Panic(msg, l.convertToProviderFields(fields))
 }
 
@@ -72,16 +164,32 @@ func (l *logger) Sync() error {
 
 // Warn implements Logger.
 func (l *logger) Warn(msg string, fields ...Field) {
-	l.provider.Warn(msg, l.convertToProviderFields(fields))
+	if l.enabled(WarnLevel) {
+		l.provider.Warn(msg, l.convertToProviderFields(fields))
+	}
 }
 
 func (l *logger) convertToProviderFields(fields []Field) map[string]any {
-	if len(fields) == 0 {
+	if len(fields) == 0 && len(l.fields) == 0 && l.name == "" {
 		return nil
 	}
-	logFields := make(map[string]any, len(fields)) // Preallocate with size
+	logFields := make(map[string]any, len(l.fields)+len(fields)+1) // Preallocate with size
+	if l.name != "" {
+		logFields[NameKey] = l.name
+	}
+	for _, field := range l.fields {
+		logFields[field.Key] = l.redact(field)
+	}
 	for _, field := range fields {
-		logFields[field.Key] = field.Value
+		logFields[field.Key] = l.redact(field)
 	}
 	return logFields
 }
+
+// redact returns the value of field with sensitive data removed when a redactor is configured.
+func (l *logger) redact(field Field) any {
+	if l.redactor == nil {
+		return field.Value
+	}
+	return l.redactor.redactField(field.Key, field.Value)
+}
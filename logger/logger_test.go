@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingProvider records the entries it receives.
+type recordingProvider struct {
+	entries []recordedEntry
+}
+
+type recordedEntry struct {
+	level  Level
+	msg    string
+	fields map[string]any
+}
+
+func (p *recordingProvider) record(level Level, msg string, fields map[string]any) {
+	p.entries = append(p.entries, recordedEntry{level: level, msg: msg, fields: fields})
+}
+
+func (p *recordingProvider) Trace(msg string, fields map[string]any) {
+	p.record(TraceLevel, msg, fields)
+}
+func (p *recordingProvider) Debug(msg string, fields map[string]any) {
+	p.record(DebugLevel, msg, fields)
+}
+func (p *recordingProvider) Info(msg string, fields map[string]any) { p.record(InfoLevel, msg, fields) }
+func (p *recordingProvider) Warn(msg string, fields map[string]any) { p.record(WarnLevel, msg, fields) }
+func (p *recordingProvider) Error(msg string, fields map[string]any) {
+	p.record(ErrorLevel, msg, fields)
+}
+func (p *recordingProvider) DPanic(msg string, fields map[string]any) {
+	p.record(DPanicLevel, msg, fields)
+}
+func (p *recordingProvider) Panic(msg string, fields map[string]any) {
+	p.record(PanicLevel, msg, fields)
+	panic(msg)
+}
+func (p *recordingProvider) Fatal(msg string, fields map[string]any) {
+	p.record(FatalLevel, msg, fields)
+}
+func (p *recordingProvider) Sync() error { return nil }
+
+func (p *recordingProvider) messages() []string {
+	var msgs []string
+	for _, e := range p.entries {
+		msgs = append(msgs, e.msg)
+	}
+	return msgs
+}
+
+func TestLoggerFiltersByLevel(t *testing.T) {
+	provider := &recordingProvider{}
+	log := New(provider, WithLevel(WarnLevel))
+
+	log.Trace("trace")
+	log.Debug("debug")
+	log.Info("info")
+	log.Warn("warn")
+	log.Error("error")
+
+	assert.Equal(t, []string{"warn", "error"}, provider.messages())
+	assert.Equal(t, WarnLevel, log.Level())
+}
+
+func TestLoggerSetLevelAtRuntime(t *testing.T) {
+	provider := &recordingProvider{}
+	log := New(provider)
+
+	log.Trace("hidden")
+	log.SetLevel(TraceLevel)
+	log.Trace("visible")
+
+	assert.Equal(t, []string{"visible"}, provider.messages())
+}
+
+func TestNamedLoggerOverride(t *testing.T) {
+	provider := &recordingProvider{}
+	levels, _ := ParseLevels("info,module.orders=debug")
+	root := New(provider, WithLevels(levels))
+	orders := New(provider, WithLevels(levels), WithName("module.orders"))
+
+	root.Debug("root debug")
+	orders.Debug("orders debug")
+
+	assert.Equal(t, []string{"orders debug"}, provider.messages())
+	assert.Equal(t, "module.orders", provider.entries[0].fields[NameKey])
+
+	orders.SetLevel(InfoLevel)
+	assert.Equal(t, InfoLevel, levels.LevelFor("module.orders"))
+	assert.Equal(t, InfoLevel, root.Level())
+}
+
+func TestLoggerPanicsWhenFiltered(t *testing.T) {
+	provider := &recordingProvider{}
+	log := New(provider, WithLevel(FatalLevel))
+
+	assert.PanicsWithValue(t, "boom", func() { log.Panic("boom") })
+	assert.Empty(t, provider.entries)
+}
+
+func TestLoggerWithAndNamed(t *testing.T) {
+	provider := &recordingProvider{}
+	root := New(provider)
+
+	child := root.Named("module").With(RequestIDField("req-1")).Named("orders").With(TenantIDField("t-1"))
+	child.Info("created", String("id", "42"))
+	root.Info("plain")
+
+	assert.Equal(t, map[string]any{
+		NameKey:     "module.orders",
+		"requestID": "req-1",
+		"tenantID":  "t-1",
+		"id":        "42",
+	}, provider.entries[0].fields)
+	assert.Nil(t, provider.entries[1].fields)
+}
+
+func TestLevelsOf(t *testing.T) {
+	levels := NewLevels(InfoLevel)
+	got, ok := LevelsOf(New(&recordingProvider{}, WithLevels(levels)).Named("module"))
+	assert.True(t, ok)
+	assert.Same(t, levels, got)
+
+	_, ok = LevelsOf(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,51 @@
+package logger
+
+// Options configures a Logger created by New.
+type Options struct {
+	Levels *Levels // shared level state; a new one at InfoLevel is created when nil
+	Name   string  // logger name used for per-name level overrides
+	// Redactor removes sensitive data from fields before they reach the provider.
+	Redactor *Redactor
+}
+
+// Option configures Options.
+type Option func(*Options)
+
+func newOptions(opts ...Option) *Options {
+	options := &Options{}
+	for _, o := range opts {
+		o(options)
+	}
+	if options.Levels == nil {
+		options.Levels = NewLevels(InfoLevel)
+	}
+	return options
+}
+
+// WithLevel sets the initial minimum level of a new level tree.
+func WithLevel(level Level) Option {
+	return func(o *Options) {
+		o.Levels = NewLevels(level)
+	}
+}
+
+// WithLevels shares existing level state, so levels can be changed at runtime.
+func WithLevels(levels *Levels) Option {
+	return func(o *Options) {
+		o.Levels = levels
+	}
+}
+
+// WithName sets the logger name used for per-name level overrides such as "module.orders".
+func WithName(name string) Option {
+	return func(o *Options) {
+		o.Name = name
+	}
+}
+
+// WithRedactor redacts sensitive fields before they reach the provider.
+func WithRedactor(r *Redactor) Option {
+	return func(o *Options) {
+		o.Redactor = r
+	}
+}
@@ -1,6 +1,8 @@
 package logger
 
 type Provider interface {
+	// Trace logs messages at the trace level, finer-grained than debug.
+	Trace(msg string, fields map[string]any)
 	// Debug logs messages at the debug level, typically used for low-level system information.
 	Debug(msg string, fields map[string]any)
 	// Info logs informational messages that highlight the progress of the application.
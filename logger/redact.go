@@ -0,0 +1,349 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RedactMode selects how sensitive values are replaced.
+type RedactMode int
+
+const (
+	// RedactMask replaces sensitive values with the mask.
+	RedactMask RedactMode = iota
+	// RedactHash replaces sensitive values with a keyed hash, so the same value
+	// always yields the same pseudonym and entries stay correlatable.
+	RedactHash
+)
+
+// DefaultRedactMask replaces sensitive values in RedactMask mode.
+const DefaultRedactMask = "[REDACTED]"
+
+// DefaultRedactKeys are the key fragments redacted by default.
+var DefaultRedactKeys = []string{"password", "token", "authorization", "secret"}
+
+// Placeholders of the values the redactor does not walk.
+const (
+	// RedactCycle replaces a pointer, map or slice already being walked.
+	RedactCycle = "<cycle>"
+	// RedactTooDeep replaces values nested deeper than maxRedactDepth.
+	RedactTooDeep = "<too deep>"
+)
+
+// maxRedactDepth bounds the nesting of the structs, maps, slices and pointers walked.
+const maxRedactDepth = 32
+
+// RedactTag is the struct tag that marks sensitive fields: `log:"redact"`.
+// `log:"-"` omits the field from log output entirely.
+const RedactTag = "log"
+
+// RedactOptions configures a Redactor.
+type RedactOptions struct {
+	// Keys are case-insensitive fragments of field keys whose values are redacted.
+	Keys []string
+	// ValuePatterns mask matching parts of string values, e.g. card numbers or e-mails.
+	ValuePatterns []*regexp.Regexp
+	// Mode selects masking or hashing.
+	Mode RedactMode
+	// Mask replaces values in RedactMask mode; DefaultRedactMask when empty.
+	Mask string
+	// HashKey is the HMAC key used in RedactHash mode.
+	HashKey []byte
+}
+
+// Redactor removes sensitive data from log fields before they reach a Provider.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	mode     RedactMode
+	mask     string
+	hashKey  []byte
+	types    sync.Map // reflect.Type -> typeInfo
+}
+
+// NewRedactor creates a Redactor from options.
+func NewRedactor(options RedactOptions) *Redactor {
+	r := &Redactor{
+		patterns: options.ValuePatterns,
+		mode:     options.Mode,
+		mask:     options.Mask,
+		hashKey:  options.HashKey,
+	}
+	for _, k := range options.Keys {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			r.keys = append(r.keys, k)
+		}
+	}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+	return r
+}
+
+// Redact returns a copy of fields with sensitive values replaced. fields is not modified.
+func (r *Redactor) Redact(fields map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return fields
+	}
+	w := &redactWalk{}
+	redacted := make(map[string]any, len(fields))
+	for k, v := range fields {
+		redacted[k] = r.field(w, k, v)
+	}
+	return redacted
+}
+
+// redactWalk tracks the values being walked by one redaction.
+type redactWalk struct {
+	visiting map[visit]bool
+	depth    int
+}
+
+// visit identifies a pointer, map or slice being walked.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// redactField redacts a single value found under key.
+func (r *Redactor) redactField(key string, v any) any {
+	return r.field(&redactWalk{}, key, v)
+}
+
+func (r *Redactor) field(w *redactWalk, key string, v any) any {
+	if r.sensitiveKey(key) {
+		return r.replace(v)
+	}
+	return r.redactValue(w, v)
+}
+
+// sensitiveKey reports whether key contains one of the configured fragments.
+func (r *Redactor) sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// replace returns the mask or the pseudonym of v.
+func (r *Redactor) replace(v any) any {
+	if r.mode != RedactHash {
+		return r.mask
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	_, _ = fmt.Fprint(mac, v)
+	return "hash:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// redactValue masks string patterns and walks structs, maps and slices.
+func (r *Redactor) redactValue(w *redactWalk, v any) any {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.maskPatterns(val)
+	}
+	rv := reflect.ValueOf(v)
+	info := r.inspect(rv.Type())
+	if !info.walk {
+		return v
+	}
+	// Values formatting themselves are kept unless they hold tagged or
+	// sensitive fields, which their own formatting would reveal.
+	switch v.(type) {
+	case error, fmt.Stringer, json.Marshaler:
+		if !info.sensitive {
+			return v
+		}
+	}
+	return r.walk(w, rv)
+}
+
+func (r *Redactor) maskPatterns(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllStringFunc(s, func(m string) string {
+			if r.mode == RedactHash {
+				return r.replace(m).(string)
+			}
+			return r.mask
+		})
+	}
+	return s
+}
+
+// walk converts structs and maps to map[string]any and slices to []any, redacting on the way.
+// Values referring back to one being walked become RedactCycle.
+func (r *Redactor) walk(w *redactWalk, rv reflect.Value) any {
+	if w.depth >= maxRedactDepth {
+		return RedactTooDeep
+	}
+	w.depth++
+	defer func() { w.depth-- }()
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		v := visit{ptr: rv.Pointer(), typ: rv.Type()}
+		if rv.Kind() == reflect.Slice {
+			v.len = rv.Len()
+		}
+		if w.visiting[v] {
+			return RedactCycle
+		}
+		if w.visiting == nil {
+			w.visiting = make(map[visit]bool)
+		}
+		w.visiting[v] = true
+		defer delete(w.visiting, v)
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return r.redactValue(w, rv.Elem().Interface())
+	case reflect.Struct:
+		out := make(map[string]any, rv.NumField())
+		r.walkStruct(w, rv, out)
+		return out
+	case reflect.Map:
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			out[key] = r.field(w, key, iter.Value().Interface())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = r.redactValue(w, rv.Index(i).Interface())
+		}
+		return out
+	}
+	return rv.Interface()
+}
+
+func (r *Redactor) walkStruct(w *redactWalk, rv reflect.Value, out map[string]any) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get(RedactTag)
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && sf.Tag.Get("json") == "" {
+			r.walkStruct(w, rv.Field(i), out)
+			continue
+		}
+		name := jsonFieldName(sf)
+		if name == "" {
+			continue
+		}
+		value := rv.Field(i).Interface()
+		if tag == "redact" {
+			out[name] = r.replace(value)
+			continue
+		}
+		out[name] = r.field(w, name, value)
+	}
+}
+
+// jsonFieldName returns the JSON key of a struct field, or "" when it is omitted.
+func jsonFieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return sf.Name
+	}
+	return name
+}
+
+// typeInfo describes what the redactor must do with values of a type.
+type typeInfo struct {
+	// walk is set when values can contain data the redactor must inspect.
+	walk bool
+	// sensitive is set when values can contain tagged fields or fields with
+	// sensitive keys, as opposed to only strings matching value patterns.
+	sensitive bool
+}
+
+// inspect returns the typeInfo of t.
+func (r *Redactor) inspect(t reflect.Type) typeInfo {
+	if cached, ok := r.types.Load(t); ok {
+		return cached.(typeInfo)
+	}
+	info := r.computeInfo(t, make(map[reflect.Type]bool))
+	r.types.Store(t, info)
+	return info
+}
+
+// computeInfo computes the typeInfo of t. visiting holds the types being
+// computed by this call, breaking cycles in recursive types; results depending
+// on such a cycle are only final for the outermost type, so only positive
+// inner results are cached.
+func (r *Redactor) computeInfo(t reflect.Type, visiting map[reflect.Type]bool) typeInfo {
+	if cached, ok := r.types.Load(t); ok {
+		return cached.(typeInfo)
+	}
+	if visiting[t] {
+		return typeInfo{}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var info typeInfo
+	merge := func(other typeInfo) {
+		info.walk = info.walk || other.walk
+		info.sensitive = info.sensitive || other.sensitive
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		merge(r.computeInfo(t.Elem(), visiting))
+	case reflect.Interface:
+		// The dynamic value is inspected when walking.
+		info.walk = true
+	case reflect.String:
+		info.walk = len(r.patterns) > 0
+	case reflect.Map:
+		if len(r.keys) > 0 {
+			info = typeInfo{walk: true, sensitive: true}
+		}
+		merge(r.computeInfo(t.Elem(), visiting))
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if tag := sf.Tag.Get(RedactTag); tag == "redact" || tag == "-" || r.sensitiveKey(jsonFieldName(sf)) {
+				info = typeInfo{walk: true, sensitive: true}
+				break
+			}
+			merge(r.computeInfo(sf.Type, visiting))
+		}
+	}
+	if info.sensitive {
+		r.types.Store(t, info)
+	}
+	return info
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Internal string `log:"-"`
+}
+
+type signupRequest struct {
+	Email       string      `json:"email" log:"redact"`
+	Plan        string      `json:"plan"`
+	Credentials credentials `json:"credentials"`
+	Tags        []string    `json:"tags"`
+}
+
+// account formats itself, including its tagged field.
+type account struct {
+	Name   string `json:"name"`
+	APIKey string `json:"api_key" log:"redact"`
+}
+
+func (a account) String() string { return a.Name + ":" + a.APIKey }
+
+// Recursive types whose sensitivity is only known through the cycle.
+type treeNode struct {
+	Child  *treeLeaf `json:"child"`
+	Secret string    `json:"-" log:"redact"`
+}
+
+type treeLeaf struct {
+	Node *treeNode `json:"node"`
+}
+
+// linkedNode refers back to itself, like entities with back-references.
+type linkedNode struct {
+	Name  string      `json:"name"`
+	Token string      `json:"token"`
+	Next  *linkedNode `json:"next"`
+}
+
+func TestRedactorKeys(t *testing.T) {
+	r := NewRedactor(RedactOptions{Keys: DefaultRedactKeys})
+
+	redacted := r.Redact(map[string]any{
+		"Authorization": "Bearer abc",
+		"accessToken":   "abc",
+		"user":          "jane",
+	})
+
+	assert.Equal(t, map[string]any{
+		"Authorization": DefaultRedactMask,
+		"accessToken":   DefaultRedactMask,
+		"user":          "jane",
+	}, redacted)
+}
+
+func TestRedactorStructTags(t *testing.T) {
+	r := NewRedactor(RedactOptions{Keys: DefaultRedactKeys})
+	req := &signupRequest{
+		Email:       "jane@example.com",
+		Plan:        "pro",
+		Credentials: credentials{Username: "jane", Password: "hunter2", Internal: "x"},
+		Tags:        []string{"a"},
+	}
+
+	redacted := r.Redact(map[string]any{"request": req})
+
+	assert.Equal(t, map[string]any{
+		"email": DefaultRedactMask,
+		"plan":  "pro",
+		"credentials": map[string]any{
+			"username": "jane",
+			"password": DefaultRedactMask,
+		},
+		"tags": []string{"a"},
+	}, redacted["request"])
+	assert.Equal(t, "hunter2", req.Credentials.Password)
+}
+
+func TestRedactorLeavesPlainStructs(t *testing.T) {
+	r := NewRedactor(RedactOptions{Keys: DefaultRedactKeys})
+	plain := struct{ Name string }{Name: "x"}
+
+	assert.Equal(t, plain, r.Redact(map[string]any{"v": plain})["v"])
+}
+
+func TestRedactorValuePatterns(t *testing.T) {
+	card := regexp.MustCompile(`\b\d{4}-\d{4}-\d{4}-\d{4}\b`)
+	r := NewRedactor(RedactOptions{ValuePatterns: []*regexp.Regexp{card}, Mask: "****"})
+
+	redacted := r.Redact(map[string]any{"note": "paid with 4111-1111-1111-1111 today"})
+
+	assert.Equal(t, "paid with **** today", redacted["note"])
+}
+
+func TestRedactorHashMode(t *testing.T) {
+	r := NewRedactor(RedactOptions{Keys: []string{"email"}, Mode: RedactHash, HashKey: []byte("k")})
+
+	first := r.Redact(map[string]any{"email": "jane@example.com"})["email"].(string)
+	second := r.Redact(map[string]any{"email": "jane@example.com"})["email"].(string)
+	other := r.Redact(map[string]any{"email": "john@example.com"})["email"].(string)
+
+	assert.True(t, strings.HasPrefix(first, "hash:"))
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+}
+
+func TestLoggerAppliesRedactor(t *testing.T) {
+	provider := &recordingProvider{}
+	log := New(provider, WithRedactor(NewRedactor(RedactOptions{Keys: DefaultRedactKeys})))
+
+	log.With(String("password", "p")).Info("login", String("user", "jane"))
+
+	assert.Equal(t, map[string]any{"password": DefaultRedactMask, "user": "jane"}, provider.entries[0].fields)
+}
+
+func TestRedactorWalksSensitiveStringers(t *testing.T) {
+	r := NewRedactor(RedactOptions{})
+
+	redacted := r.Redact(map[string]any{"account": account{Name: "svc", APIKey: "k-123"}})
+
+	assert.Equal(t, map[string]any{"name": "svc", "api_key": DefaultRedactMask}, redacted["account"])
+}
+
+func TestRedactorRecursiveTypes(t *testing.T) {
+	r := NewRedactor(RedactOptions{})
+
+	// Inspecting the node first must not mark the leaf as plain.
+	r.Redact(map[string]any{"node": treeNode{}})
+	redacted := r.Redact(map[string]any{"leaf": treeLeaf{Node: &treeNode{Secret: "s"}}})
+
+	assert.Equal(t, map[string]any{"node": map[string]any{"child": nil}}, redacted["leaf"])
+}
+
+func TestRedactorCycles(t *testing.T) {
+	r := NewRedactor(RedactOptions{Keys: DefaultRedactKeys})
+
+	node := &linkedNode{Name: "a", Token: "t"}
+	node.Next = node
+	self := map[string]any{"password": "p"}
+	self["self"] = self
+	redacted := r.Redact(map[string]any{"node": node, "map": self})
+
+	assert.Equal(t, map[string]any{"name": "a", "token": DefaultRedactMask, "next": RedactCycle}, redacted["node"])
+	assert.Equal(t, map[string]any{"password": DefaultRedactMask, "self": RedactCycle}, redacted["map"])
+
+	// Shared values that are not cycles are rendered each time.
+	shared := &linkedNode{Name: "b"}
+	redacted = r.Redact(map[string]any{"pair": []*linkedNode{shared, shared}})
+	assert.Equal(t, []any{
+		map[string]any{"name": "b", "token": DefaultRedactMask, "next": nil},
+		map[string]any{"name": "b", "token": DefaultRedactMask, "next": nil},
+	}, redacted["pair"])
+
+	// Long chains stop at the depth cap.
+	head := &linkedNode{}
+	for i := 0; i < 2*maxRedactDepth; i++ {
+		head = &linkedNode{Next: head}
+	}
+	value := r.Redact(map[string]any{"chain": head})["chain"]
+	for depth := 0; ; depth++ {
+		m, ok := value.(map[string]any)
+		if !ok {
+			assert.Equal(t, RedactTooDeep, value)
+			assert.Less(t, depth, maxRedactDepth)
+			break
+		}
+		value = m["next"]
+	}
+}
@@ -0,0 +1,369 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is the timestamp layout in rotated file names.
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateOptions configures a RotatingFile.
+type RotateOptions struct {
+	// Filename is the path of the active log file.
+	Filename string
+	// MaxSize rotates the file once it would grow beyond this many bytes; 0 disables size rotation.
+	MaxSize int64
+	// Interval rotates the file at every multiple of the interval, e.g. 24h; 0 disables time rotation.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep; 0 keeps all.
+	MaxBackups int
+	// MaxAge removes rotated files older than this; 0 keeps them regardless of age.
+	MaxAge time.Duration
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// RotatingFile is a WriteSyncer that writes to a file and rotates it by size
+// and time. Rotated files are named after the active file with a timestamp,
+// e.g. app-20240501T120000.000.log, optionally gzipped, and pruned by count and age.
+type RotatingFile struct {
+	options RotateOptions
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	now       func() time.Time
+	rename    func(oldpath, newpath string) error
+	mill      chan struct{}
+	millDone  chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRotatingFile opens, or creates, the log file and starts the background
+// goroutine that compresses and prunes rotated files.
+func NewRotatingFile(options RotateOptions) (*RotatingFile, error) {
+	if options.Filename == "" {
+		return nil, errors.New("logger: rotating file requires a filename")
+	}
+	f := &RotatingFile{
+		options:  options,
+		now:      time.Now,
+		rename:   os.Rename,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	go f.runMill()
+	return f, nil
+}
+
+// Write implements io.Writer, rotating the file first when needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, ErrWriterClosed
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			// The active file was reopened; keep logging to it.
+			fmt.Fprintf(os.Stderr, "logger: failed to rotate %s: %v\n", f.options.Filename, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Sync commits the active file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Rotate closes the active file, renames it to a backup and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Reopen closes and reopens the active file by name. It is used after an
+// external tool such as logrotate has moved the file away.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	return f.open()
+}
+
+// ReopenOnSignal reopens the file whenever one of the signals, SIGHUP by
+// default, is received. Call the returned function to stop listening.
+func (f *RotatingFile) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := f.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "logger: failed to reopen %s: %v\n", f.options.Filename, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Close closes the active file and waits for pending compression and pruning.
+func (f *RotatingFile) Close() error {
+	var err error
+	f.closeOnce.Do(func() {
+		f.mu.Lock()
+		if f.file != nil {
+			err = f.file.Close()
+			f.file = nil
+		}
+		f.mu.Unlock()
+		close(f.mill)
+		<-f.millDone
+	})
+	return err
+}
+
+// shouldRotate reports whether writing n more bytes requires a rotation.
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.options.MaxSize > 0 && f.size > 0 && f.size+n > f.options.MaxSize {
+		return true
+	}
+	return f.options.Interval > 0 && !f.now().Before(f.nextRotation)
+}
+
+// open opens the active file in append mode.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.options.Filename), 0o755); err != nil {
+		return fmt.Errorf("logger: create log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.options.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("logger: open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("logger: stat log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.options.Interval > 0 {
+		f.nextRotation = f.now().Truncate(f.options.Interval).Add(f.options.Interval)
+	}
+	return nil
+}
+
+// rotate must be called with mu held. When the file cannot be renamed, it is
+// reopened so that writing continues to it.
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	if err := f.rename(f.options.Filename, f.backupName(f.now())); err != nil && !os.IsNotExist(err) {
+		err = fmt.Errorf("logger: rename log file: %w", err)
+		if openErr := f.open(); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	select {
+	case f.mill <- struct{}{}:
+	default: // a run is already pending
+	}
+	return nil
+}
+
+// backupName returns an unused rotated file name for time t. Rotations within
+// the same millisecond get a sequence suffix, e.g. app-20240501T120000.000-1.log.
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	stamp := t.Format(backupTimeFormat)
+	for seq := 0; ; seq++ {
+		name := prefix + stamp
+		if seq > 0 {
+			name += "-" + strconv.Itoa(seq)
+		}
+		path := filepath.Join(dir, name+ext)
+		if !exists(path) && !exists(path+".gz") {
+			return path
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// nameParts splits the file name into directory, "name-" prefix and extension.
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.options.Filename)
+	base := filepath.Base(f.options.Filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (f *RotatingFile) runMill() {
+	defer close(f.millDone)
+	for range f.mill {
+		if err := f.millRun(); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: failed to process rotated logs: %v\n", err)
+		}
+	}
+}
+
+// backup is a rotated file.
+type backup struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// millRun compresses rotated files and removes the ones beyond MaxBackups or MaxAge.
+func (f *RotatingFile) millRun() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	cutoff := f.now().Add(-f.options.MaxAge)
+	for i, b := range backups {
+		expired := f.options.MaxBackups > 0 && i >= f.options.MaxBackups ||
+			f.options.MaxAge > 0 && b.time.Before(cutoff)
+		if expired {
+			errs = append(errs, os.Remove(b.path))
+			continue
+		}
+		if f.options.Compress && !strings.HasSuffix(b.path, ".gz") {
+			errs = append(errs, compressFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backups returns the rotated files, newest first.
+func (f *RotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		var seq int
+		if len(stamp) > len(backupTimeFormat) {
+			n, err := strconv.Atoi(strings.TrimPrefix(stamp[len(backupTimeFormat):], "-"))
+			if err != nil || n <= 0 || stamp[len(backupTimeFormat)] != '-' {
+				continue
+			}
+			stamp, seq = stamp[:len(backupTimeFormat)], n
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t, seq: seq})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+// compressFile gzips path into path.gz and removes the original.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
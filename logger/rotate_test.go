@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns a controllable time source.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestRotatingFile(t *testing.T, options RotateOptions) (*RotatingFile, *fakeClock) {
+	t.Helper()
+	f, err := NewRotatingFile(options)
+	require.NoError(t, err)
+	clock := &fakeClock{t: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+	f.now = clock.now
+	if options.Interval > 0 {
+		f.nextRotation = clock.t.Truncate(options.Interval).Add(options.Interval)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return f, clock
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	f, clock := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxSize: 10})
+
+	_, _ = f.Write([]byte("12345678\n"))
+	clock.advance(time.Second)
+	_, _ = f.Write([]byte("abc\n"))
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-20240501T100001.000.log", "app.log"}, dirNames(t, dir))
+	data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "abc\n", string(data))
+}
+
+func TestRotatingFileKeepsWritingWhenRenameFails(t *testing.T) {
+	dir := t.TempDir()
+	f, clock := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxSize: 10})
+	f.rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+
+	_, err := f.Write([]byte("12345678\n"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("abc\n"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, f.Rotate(), syscall.EXDEV)
+	_, err = f.Write([]byte("def\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"app.log"}, dirNames(t, dir))
+	data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "12345678\nabc\ndef\n", string(data))
+
+	// Rotation resumes once the file can be renamed.
+	f.rename = os.Rename
+	clock.advance(time.Second)
+	_, err = f.Write([]byte("ghi\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, []string{"app-20240501T100001.000.log", "app.log"}, dirNames(t, dir))
+}
+
+func TestRotatingFileRotatesByTime(t *testing.T) {
+	dir := t.TempDir()
+	f, clock := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), Interval: time.Hour})
+
+	_, _ = f.Write([]byte("first\n"))
+	clock.advance(30 * time.Minute)
+	_, _ = f.Write([]byte("second\n"))
+	clock.advance(30 * time.Minute)
+	_, _ = f.Write([]byte("third\n"))
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-20240501T110000.000.log", "app.log"}, dirNames(t, dir))
+	data, _ := os.ReadFile(filepath.Join(dir, "app-20240501T110000.000.log"))
+	assert.Equal(t, "first\nsecond\n", string(data))
+}
+
+func TestRotatingFileRetentionAndCompression(t *testing.T) {
+	dir := t.TempDir()
+	f, clock := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxBackups: 2, Compress: true})
+
+	for i := 0; i < 4; i++ {
+		_, _ = f.Write([]byte("line\n"))
+		clock.advance(time.Second)
+		require.NoError(t, f.Rotate())
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-20240501T100003.000.log.gz", "app-20240501T100004.000.log.gz", "app.log"}, dirNames(t, dir))
+
+	gzFile, err := os.Open(filepath.Join(dir, "app-20240501T100004.000.log.gz"))
+	require.NoError(t, err)
+	defer gzFile.Close()
+	gz, err := gzip.NewReader(gzFile)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(gz)
+	assert.Equal(t, "line\n", string(data))
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	f, clock := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxAge: time.Hour})
+
+	require.NoError(t, f.Rotate())
+	clock.advance(2 * time.Hour)
+	require.NoError(t, f.Rotate())
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-20240501T120000.000.log", "app.log"}, dirNames(t, dir))
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, _ := newTestRotatingFile(t, RotateOptions{Filename: path})
+
+	_, _ = f.Write([]byte("before\n"))
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, f.Reopen())
+	_, _ = f.Write([]byte("after\n"))
+
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "after\n", string(data))
+	moved, _ := os.ReadFile(path + ".1")
+	assert.Equal(t, "before\n", string(moved))
+}
+
+func TestRotatingFileWithEncoder(t *testing.T) {
+	dir := t.TempDir()
+	f, _ := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log")})
+
+	New(NewDefaultLogger("production", WithOutput(f), WithEncoder(NewLogfmtEncoder()))).Info("to file")
+	require.NoError(t, f.Sync())
+
+	data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Contains(t, string(data), "message=\"to file\"")
+}
+
+func TestRotatingFileRotatesWithinOneMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	f, _ := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxSize: 4, MaxBackups: 2})
+
+	for _, line := range []string{"one\n", "two\n", "six\n", "ten\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-20240501T100000.000-1.log", "app-20240501T100000.000-2.log", "app.log"}, dirNames(t, dir))
+	data, _ := os.ReadFile(filepath.Join(dir, "app-20240501T100000.000-2.log"))
+	assert.Equal(t, "six\n", string(data))
+}
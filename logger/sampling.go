@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+// counterSlots is the number of counters per level; messages are hashed into them.
+const counterSlots = 4096
+
+// SamplingOptions configures per-message sampling.
+type SamplingOptions struct {
+	// Initial is the number of entries with the same level and message logged per Tick.
+	Initial int
+	// Thereafter logs every Thereafter-th entry once Initial is exceeded; 0 drops them all.
+	Thereafter int
+	// Tick is the interval after which the counters reset.
+	Tick time.Duration
+}
+
+// SampledProvider is a Provider decorator that samples repeated messages.
+// Trace to error entries are sampled; DPanic, Panic and Fatal always pass.
+type SampledProvider struct {
+	Provider
+	options  SamplingOptions
+	counters [ErrorLevel - TraceLevel + 1][counterSlots]counter
+	dropped  atomic.Uint64
+}
+
+// NewSampledProvider wraps p so that, per level and message, the first Initial
+// entries of every Tick are logged and then every Thereafter-th entry.
+func NewSampledProvider(p Provider, options SamplingOptions) *SampledProvider {
+	if options.Tick <= 0 {
+		options.Tick = time.Second
+	}
+	return &SampledProvider{Provider: p, options: options}
+}
+
+// Dropped returns the number of entries dropped by sampling.
+func (s *SampledProvider) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// sample reports whether an entry with the given level and message is logged.
+func (s *SampledProvider) sample(level Level, msg string) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(msg))
+	c := &s.counters[level-TraceLevel][h.Sum32()%counterSlots]
+
+	n := c.inc(time.Now().UnixNano(), s.options.Tick)
+	if n <= uint64(s.options.Initial) {
+		return true
+	}
+	if s.options.Thereafter > 0 && (n-uint64(s.options.Initial))%uint64(s.options.Thereafter) == 0 {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// Trace implements Provider.
+func (s *SampledProvider) Trace(msg string, fields map[string]any) {
+	if s.sample(TraceLevel, msg) {
+		s.Provider.Trace(msg, fields)
+	}
+}
+
+// Debug implements Provider.
+func (s *SampledProvider) Debug(msg string, fields map[string]any) {
+	if s.sample(DebugLevel, msg) {
+		s.Provider.Debug(msg, fields)
+	}
+}
+
+// Info implements Provider.
+func (s *SampledProvider) Info(msg string, fields map[string]any) {
+	if s.sample(InfoLevel, msg) {
+		s.Provider.Info(msg, fields)
+	}
+}
+
+// Warn implements Provider.
+func (s *SampledProvider) Warn(msg string, fields map[string]any) {
+	if s.sample(WarnLevel, msg) {
+		s.Provider.Warn(msg, fields)
+	}
+}
+
+// Error implements Provider.
+func (s *SampledProvider) Error(msg string, fields map[string]any) {
+	if s.sample(ErrorLevel, msg) {
+		s.Provider.Error(msg, fields)
+	}
+}
+
+// counter counts entries within the current tick.
+type counter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// inc increments the counter, resetting it first when the tick has elapsed.
+func (c *counter) inc(now int64, tick time.Duration) uint64 {
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.count.Add(1)
+	}
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick.Nanoseconds()) {
+		// Another goroutine reset the counter concurrently.
+		return c.count.Add(1)
+	}
+	return 1
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampledProvider(t *testing.T) {
+	provider := &recordingProvider{}
+	sampled := NewSampledProvider(provider, SamplingOptions{Initial: 2, Thereafter: 3, Tick: time.Minute})
+	log := New(sampled)
+
+	for i := 0; i < 10; i++ {
+		log.Info("hot path")
+	}
+	log.Info("other message")
+
+	// Entries 1 and 2 pass, then every third: 5 and 8.
+	assert.Equal(t, []string{"hot path", "hot path", "hot path", "hot path", "other message"}, provider.messages())
+	assert.Equal(t, uint64(6), sampled.Dropped())
+}
+
+func TestSampledProviderResetsAfterTick(t *testing.T) {
+	provider := &recordingProvider{}
+	sampled := NewSampledProvider(provider, SamplingOptions{Initial: 1, Tick: 20 * time.Millisecond})
+
+	sampled.Warn("flaky", nil)
+	sampled.Warn("flaky", nil)
+	time.Sleep(30 * time.Millisecond)
+	sampled.Warn("flaky", nil)
+
+	assert.Len(t, provider.entries, 2)
+}
+
+func TestSampledProviderLevelsAreIndependent(t *testing.T) {
+	provider := &recordingProvider{}
+	sampled := NewSampledProvider(provider, SamplingOptions{Initial: 1, Tick: time.Minute})
+
+	sampled.Info("same", nil)
+	sampled.Error("same", nil)
+	sampled.Error("same", nil)
+
+	assert.Len(t, provider.entries, 2)
+	assert.PanicsWithValue(t, "same", func() { sampled.Panic("same", nil) })
+}
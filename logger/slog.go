@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// slog levels used for the ebrick levels that slog does not define.
+const (
+	SlogLevelTrace  = slog.Level(-8)
+	SlogLevelDPanic = slog.Level(10)
+	SlogLevelPanic  = slog.Level(12)
+	SlogLevelFatal  = slog.Level(16)
+)
+
+// ToSlogLevel converts a logger Level into the matching slog.Level.
+func ToSlogLevel(l Level) slog.Level {
+	switch l {
+	case TraceLevel:
+		return SlogLevelTrace
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case DPanicLevel:
+		return SlogLevelDPanic
+	case PanicLevel:
+		return SlogLevelPanic
+	}
+	return SlogLevelFatal
+}
+
+// FromSlogLevel converts a slog.Level into the closest logger Level.
+func FromSlogLevel(l slog.Level) Level {
+	switch {
+	case l < slog.LevelDebug:
+		return TraceLevel
+	case l < slog.LevelInfo:
+		return DebugLevel
+	case l < slog.LevelWarn:
+		return InfoLevel
+	case l < slog.LevelError:
+		return WarnLevel
+	case l < SlogLevelDPanic:
+		return ErrorLevel
+	case l < SlogLevelPanic:
+		return DPanicLevel
+	case l < SlogLevelFatal:
+		return PanicLevel
+	}
+	return FatalLevel
+}
+
+// slogProvider implements Provider on top of a *slog.Logger.
+type slogProvider struct {
+	logger *slog.Logger
+}
+
+// NewSlogProvider returns a Provider that writes through l.
+// Panic and DPanic log and then panic, Fatal logs and then exits.
+func NewSlogProvider(l *slog.Logger) Provider {
+	return &slogProvider{logger: l}
+}
+
+func (p *slogProvider) log(level slog.Level, msg string, fields map[string]any) {
+	ctx := context.Background()
+	if !p.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, k := range sortedKeys(fields) {
+		attrs = append(attrs, slog.Any(k, fields[k]))
+	}
+	p.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// Trace implements Provider.
+func (p *slogProvider) Trace(msg string, fields map[string]any) {
+	p.log(SlogLevelTrace, msg, fields)
+}
+
+// Debug implements Provider.
+func (p *slogProvider) Debug(msg string, fields map[string]any) {
+	p.log(slog.LevelDebug, msg, fields)
+}
+
+// Info implements Provider.
+func (p *slogProvider) Info(msg string, fields map[string]any) {
+	p.log(slog.LevelInfo, msg, fields)
+}
+
+// Warn implements Provider.
+func (p *slogProvider) Warn(msg string, fields map[string]any) {
+	p.log(slog.LevelWarn, msg, fields)
+}
+
+// Error implements Provider.
+func (p *slogProvider) Error(msg string, fields map[string]any) {
+	p.log(slog.LevelError, msg, fields)
+}
+
+// DPanic implements Provider.
+func (p *slogProvider) DPanic(msg string, fields map[string]any) {
+	p.log(SlogLevelDPanic, msg, fields)
+	panic(msg)
+}
+
+// Panic implements Provider.
+func (p *slogProvider) Panic(msg string, fields map[string]any) {
+	p.log(SlogLevelPanic, msg, fields)
+	panic(msg)
+}
+
+// Fatal implements Provider.
+func (p *slogProvider) Fatal(msg string, fields map[string]any) {
+	p.log(SlogLevelFatal, msg, fields)
+	os.Exit(1)
+}
+
+// Sync implements Provider. slog handlers write synchronously, so there is nothing to flush.
+func (p *slogProvider) Sync() error {
+	return nil
+}
+
+// slogHandler implements slog.Handler on top of a Logger.
+type slogHandler struct {
+	logger Logger
+	group  string // dotted prefix for attribute keys
+}
+
+// NewSlogHandler returns a slog.Handler that routes records through l, so
+// standard library and third-party slog output ends up in the ebrick pipeline.
+// Groups are flattened into dotted keys. Records at or above slog.LevelError
+// are logged at the error level and never panic or exit.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Level().Enabled(FromSlogLevel(level))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+
+	switch level := FromSlogLevel(r.Level); {
+	case level <= TraceLevel:
+		h.logger.Trace(r.Message, fields...)
+	case level == DebugLevel:
+		h.logger.Debug(r.Message, fields...)
+	case level == InfoLevel:
+		h.logger.Info(r.Message, fields...)
+	case level == WarnLevel:
+		h.logger.Warn(r.Message, fields...)
+	default:
+		h.logger.Error(r.Message, fields...)
+	}
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &slogHandler{logger: h.logger.With(fields...), group: h.group}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+// appendAttr flattens a slog attribute into fields, expanding groups into dotted keys.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Any(prefix+a.Key, a.Value.Any()))
+}
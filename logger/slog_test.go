@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogProvider(t *testing.T) {
+	var buf bytes.Buffer
+	sl := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: SlogLevelTrace}))
+	log := New(NewSlogProvider(sl), WithLevel(TraceLevel))
+
+	log.Trace("tracing", String("step", "parse"))
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "tracing", entry["msg"])
+	assert.Equal(t, "DEBUG-4", entry["level"])
+	assert.Equal(t, "parse", entry["step"])
+}
+
+func TestSlogProviderPanics(t *testing.T) {
+	var buf bytes.Buffer
+	provider := NewSlogProvider(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	assert.PanicsWithValue(t, "boom", func() { provider.Panic("boom", nil) })
+	assert.Contains(t, buf.String(), "msg=boom")
+}
+
+func TestSlogHandler(t *testing.T) {
+	provider := &recordingProvider{}
+	sl := slog.New(NewSlogHandler(New(provider, WithLevel(DebugLevel))))
+
+	sl.With("component", "billing").WithGroup("req").Info("charged", "amount", 12, slog.Group("card", "brand", "visa"))
+	sl.Debug("debugging")
+	sl.Log(context.Background(), SlogLevelTrace, "hidden")
+	sl.Error("failed", "error", errors.New("declined"))
+
+	assert.Equal(t, []string{"charged", "debugging", "failed"}, provider.messages())
+	assert.Equal(t, map[string]any{
+		"component":      "billing",
+		"req.amount":     int64(12),
+		"req.card.brand": "visa",
+	}, provider.entries[0].fields)
+	assert.Equal(t, ErrorLevel, provider.entries[2].level)
+}
+
+func TestSlogLevelConversion(t *testing.T) {
+	for _, level := range []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel} {
+		assert.Equal(t, level, FromSlogLevel(ToSlogLevel(level)))
+	}
+}
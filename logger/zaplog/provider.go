@@ -0,0 +1,105 @@
+// Package zaplog adapts go.uber.org/zap to the ebrick logger.
+package zaplog
+
+import (
+	"sort"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// provider implements logger.Provider on top of a *zap.Logger.
+type provider struct {
+	logger *zap.Logger
+}
+
+// NewProvider returns a logger.Provider that writes through z.
+// zap has no trace level, so trace entries are written at debug level. When z
+// adds the caller, it is the first frame outside the logger packages, however
+// many providers wrap this one.
+func NewProvider(z *zap.Logger) logger.Provider {
+	return &provider{logger: z}
+}
+
+// write logs through zap with the caller found by logger.CallerFrame.
+func (p *provider) write(level zapcore.Level, msg string, fields map[string]any) {
+	ce := p.logger.Check(level, msg)
+	if ce == nil {
+		return
+	}
+	if ce.Caller.Defined {
+		if frame, ok := logger.CallerFrame(); ok {
+			ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+			ce.Caller.Function = frame.Function
+		}
+	}
+	ce.Write(toZapFields(fields)...)
+}
+
+// Trace implements logger.Provider.
+func (p *provider) Trace(msg string, fields map[string]any) {
+	p.write(zapcore.DebugLevel, msg, fields)
+}
+
+// Debug implements logger.Provider.
+func (p *provider) Debug(msg string, fields map[string]any) {
+	p.write(zapcore.DebugLevel, msg, fields)
+}
+
+// Info implements logger.Provider.
+func (p *provider) Info(msg string, fields map[string]any) {
+	p.write(zapcore.InfoLevel, msg, fields)
+}
+
+// Warn implements logger.Provider.
+func (p *provider) Warn(msg string, fields map[string]any) {
+	p.write(zapcore.WarnLevel, msg, fields)
+}
+
+// Error implements logger.Provider.
+func (p *provider) Error(msg string, fields map[string]any) {
+	p.write(zapcore.ErrorLevel, msg, fields)
+}
+
+// DPanic implements logger.Provider.
+func (p *provider) DPanic(msg string, fields map[string]any) {
+	p.write(zapcore.DPanicLevel, msg, fields)
+}
+
+// Panic implements logger.Provider.
+func (p *provider) Panic(msg string, fields map[string]any) {
+	p.write(zapcore.PanicLevel, msg, fields)
+}
+
+// Fatal implements logger.Provider.
+func (p *provider) Fatal(msg string, fields map[string]any) {
+	p.write(zapcore.FatalLevel, msg, fields)
+}
+
+// Sync implements logger.Provider.
+func (p *provider) Sync() error {
+	return p.logger.Sync()
+}
+
+// toZapFields converts provider fields into zap fields in key order.
+func toZapFields(fields map[string]any) []zap.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	zapFields := make([]zap.Field, 0, len(fields))
+	for _, k := range keys {
+		if err, ok := fields[k].(error); ok {
+			zapFields = append(zapFields, zap.NamedError(k, err))
+			continue
+		}
+		zapFields = append(zapFields, zap.Any(k, fields[k]))
+	}
+	return zapFields
+}
@@ -0,0 +1,53 @@
+package zaplog
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestProvider(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := logger.New(NewProvider(zap.New(core)), logger.WithLevel(logger.DebugLevel))
+
+	log.Named("orders").Info("order created", logger.String("id", "42"))
+	log.Error("order failed", logger.Error(errors.New("timeout")))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "order created", entries[0].Message)
+	assert.Equal(t, map[string]any{"id": "42", logger.NameKey: "orders"}, entries[0].ContextMap())
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+	assert.Equal(t, "timeout", entries[1].ContextMap()["error"])
+}
+
+func TestProviderPanic(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	provider := NewProvider(zap.New(core))
+
+	assert.Panics(t, func() { provider.Panic("boom", nil) })
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestProviderCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	provider := NewProvider(zap.New(core, zap.AddCaller()))
+	sampled := logger.NewSampledProvider(provider, logger.SamplingOptions{Initial: 10, Tick: time.Minute})
+
+	logger.New(provider).Info("direct")
+	logger.New(sampled).Named("orders").Info("wrapped")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "provider_test.go", filepath.Base(entry.Caller.File), entry.Message)
+		assert.Contains(t, entry.Caller.Function, "TestProviderCaller", entry.Message)
+	}
+}
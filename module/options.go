@@ -2,16 +2,19 @@ package module
 
 import (
 	"github.com/ebrickdev/ebrick/cache"
+	"github.com/ebrickdev/ebrick/db"
 	"github.com/ebrickdev/ebrick/logger"
 	"github.com/ebrickdev/ebrick/messaging"
 	"gorm.io/gorm"
 )
 
 type Options struct {
-	Cache    cache.Cache
-	Logger   logger.Logger
-	EventBus messaging.EventBus
-	Db       *gorm.DB
+	Cache       cache.Cache
+	Logger      logger.Logger
+	EventBus    messaging.EventBus
+	Db          *gorm.DB
+	DataSources *db.DataSources
+	CursorKey   []byte // Signs the pagination cursors of NewCrudRepository
 }
 
 type Option func(*Options)
@@ -49,3 +52,28 @@ func WithDB(db *gorm.DB) Option {
 		o.Db = db
 	}
 }
+
+func WithDataSources(d *db.DataSources) Option {
+	return func(o *Options) {
+		o.DataSources = d
+	}
+}
+
+// WithCursorKey sets the key signing the pagination cursors of NewCrudRepository.
+func WithCursorKey(key []byte) Option {
+	return func(o *Options) {
+		o.CursorKey = key
+	}
+}
+
+// DataSource returns the named data source, e.g. db.ReplicaDataSource. The
+// primary falls back to Db.
+func (o *Options) DataSource(name string) (*gorm.DB, bool) {
+	if ds, ok := o.DataSources.Get(name); ok {
+		return ds, true
+	}
+	if name == db.PrimaryDataSource && o.Db != nil {
+		return o.Db, true
+	}
+	return nil, false
+}
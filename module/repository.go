@@ -0,0 +1,24 @@
+package module
+
+import (
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/ebrickdev/ebrick/tenancy"
+)
+
+// NewCrudRepository returns a repository for T on the module's database,
+// signing cursors with the module's CursorKey. Reads
+// are routed to the replica data source when one is configured. When the
+// application provides a cache, FindByID and spec lookups are served from it,
+// partitioned by the tenant of the context; see repository.WithCache.
+func NewCrudRepository[T any, ID comparable](options *Options, opts ...repository.CacheOption) repository.CrudRepository[T, ID] {
+	var repoOpts []repository.Option
+	if len(options.CursorKey) > 0 {
+		repoOpts = append(repoOpts, repository.WithCursorKey(options.CursorKey))
+	}
+	if replica, ok := options.DataSource(db.ReplicaDataSource); ok {
+		repoOpts = append(repoOpts, repository.WithReadDB(replica))
+	}
+	opts = append([]repository.CacheOption{repository.WithCacheScope(tenancy.CacheScope)}, opts...)
+	return repository.WithCache(repository.NewCrudRepository[T, ID](options.Db, repoOpts...), options.Cache, opts...)
+}
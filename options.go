@@ -1,13 +1,20 @@
 package ebrick
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
 
 	"github.com/ebrickdev/ebrick/cache"
 	"github.com/ebrickdev/ebrick/config"
+	"github.com/ebrickdev/ebrick/db"
 	"github.com/ebrickdev/ebrick/logger"
 	"github.com/ebrickdev/ebrick/messaging"
 	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/ebrickdev/ebrick/tenancy"
 	"github.com/ebrickdev/ebrick/transport/grpc"
 	"github.com/ebrickdev/ebrick/transport/http"
 	"gorm.io/gorm"
@@ -15,15 +22,27 @@ import (
 
 // Options holds both configuration values and runtime dependencies
 type Options struct {
-	Name        string             // Application name
-	Version     string             // Application version
-	Cache       cache.Cache        // Cache instance
-	Logger      logger.Logger      // Logger instance
-	EventBus    messaging.EventBus // Event bus instance for inter-component communication
-	http        http.HTTPServer    // HTTP server instance
-	GRPCServer  grpc.GRPCServer    // gRPC server instance; optional
-	DB          *gorm.DB
-	AuthManager auth.AuthManager
+	Name               string             // Application name
+	Version            string             // Application version
+	Cache              cache.Cache        // Cache instance
+	Logger             logger.Logger      // Logger instance
+	EventBus           messaging.EventBus // Event bus instance for inter-component communication
+	http               http.HTTPServer    // HTTP server instance
+	GRPCServer         grpc.GRPCServer    // gRPC server instance; optional
+	DB                 *gorm.DB
+	DataSources        *db.DataSources // Named data sources; DB is the primary
+	AuthManager        auth.AuthManager
+	LogLevels          *logger.Levels     // Runtime-adjustable log levels of the default logger
+	LogLevelPath       string             // HTTP path of the log level admin endpoint; disabled when empty
+	LogLevelMiddleware []http.HandlerFunc // Run before the log level endpoint, e.g. to authenticate
+	AuditActor         string             // Recorded in CreatedBy/UpdatedBy when no principal is authenticated
+	StrictTenancy      bool               // Reject queries on tenant entities that run without a tenant
+	Tenants            *tenancy.Manager   // Manager of the tenants with their own schema or database
+	TenantPath         string             // HTTP path of the tenant admin endpoint; disabled when empty
+	TenantMiddleware   []http.HandlerFunc // Run before the tenant endpoint, e.g. to authenticate
+	CursorKey          []byte             // Signs the pagination cursors of module repositories
+
+	shutdown []func() // Run when the application stops, before the logger is synced
 }
 
 // Option defines a function type to configure Options
@@ -34,6 +53,7 @@ type Option func(*Options)
 func newOptions(opts ...Option) *Options {
 	// Retrieve the application configuration
 	cfg := config.GetAppConfig()
+	config.RegisterSection("app", cfg)
 
 	// Initialize Options with a default Logger (could be overridden by WithLogger)
 	opt := &Options{}
@@ -45,10 +65,138 @@ func newOptions(opts ...Option) *Options {
 
 	// Ensure Logger is initialized
 	if opt.Logger == nil {
+		levels, err := newLogLevels(cfg)
+		if err != nil {
+			log.Fatalf("invalid log level configuration: %v", err)
+		}
+		if opt.LogLevels == nil {
+			opt.LogLevels = levels
+		}
 		// Create a new default logger based on the environment
-		logger.DefaultLogger = logger.New(logger.NewDefaultLogger(cfg.Env))
+		logger.DefaultLevels = opt.LogLevels
+		var providerOpts []logger.DefaultOption
+		format := cfg.Log.Format
+		if format == "" && cfg.Log.File.Path != "" {
+			// The console encoder's colours do not belong in a file.
+			format = "json"
+		}
+		if format != "" {
+			encoder, err := logger.EncoderByName(format)
+			if err != nil {
+				log.Fatalf("invalid log format configuration: %v", err)
+			}
+			providerOpts = append(providerOpts, logger.WithEncoder(encoder))
+		}
+		out, errOut := io.Writer(os.Stdout), io.Writer(os.Stderr)
+		if file := cfg.Log.File; file.Path != "" {
+			rf, err := logger.NewRotatingFile(logger.RotateOptions{
+				Filename:   file.Path,
+				MaxSize:    int64(file.MaxSizeMB) * 1024 * 1024,
+				Interval:   file.Interval,
+				MaxBackups: file.MaxBackups,
+				MaxAge:     file.MaxAge,
+				Compress:   file.Compress,
+			})
+			if err != nil {
+				log.Fatalf("failed to open log file: %v", err)
+			}
+			opt.shutdown = append(opt.shutdown, rf.ReopenOnSignal())
+			out, errOut = rf, rf
+		}
+		drops := make(map[string]logger.DropCounter)
+		if cfg.Log.Async {
+			asyncOut := logger.NewAsyncWriter(out, cfg.Log.QueueSize)
+			drops["async"] = asyncOut
+			out = asyncOut
+			if errOut == os.Stderr {
+				asyncErrOut := logger.NewAsyncWriter(errOut, cfg.Log.QueueSize)
+				drops["async_stderr"] = asyncErrOut
+				errOut = asyncErrOut
+			} else {
+				errOut = out
+			}
+		}
+		providerOpts = append(providerOpts, logger.WithOutput(out), logger.WithErrorOutput(errOut))
+		var provider logger.Provider = logger.NewDefaultLogger(cfg.Env, providerOpts...)
+		if sampling := cfg.Log.Sampling; sampling.Enabled {
+			sampled := logger.NewSampledProvider(provider, logger.SamplingOptions{
+				Initial:    sampling.Initial,
+				Thereafter: sampling.Thereafter,
+				Tick:       sampling.Tick,
+			})
+			drops["sampling"] = sampled
+			provider = sampled
+		}
+		logger.DefaultLogger = logger.New(provider, logger.WithLevels(opt.LogLevels), logger.WithRedactor(newLogRedactor(cfg)))
 		opt.Logger = logger.DefaultLogger
-		opt.Logger.Info("Default logger initiated")
+		if len(drops) > 0 {
+			opt.shutdown = append(opt.shutdown, logger.ReportDropped(opt.Logger, logger.DefaultDropReportInterval, drops))
+		}
+		opt.Logger.Info("Default logger initiated", logger.String("level", opt.LogLevels.Level().String()))
+	}
+
+	// Sign pagination cursors with one key, so they verify on every repository
+	// and, when configured, on every instance and after a restart.
+	if len(opt.CursorKey) == 0 {
+		opt.CursorKey = []byte(cfg.Repository.CursorKey)
+	}
+	if len(opt.CursorKey) == 0 {
+		opt.CursorKey = make([]byte, 32)
+		if _, err := rand.Read(opt.CursorKey); err != nil {
+			opt.Logger.Fatal("failed to generate cursor key", logger.Error(err))
+		}
+		opt.Logger.Warn("repository.cursorKey is not set; pagination cursors are signed with a random key and are rejected by other instances and after a restart")
+	}
+
+	// Open the data sources configured in application.yaml if none are provided.
+	// A DB given with WithDB replaces the configured primary; the other named data
+	// sources are still opened. The application exits when one cannot be opened.
+	if opt.DataSources == nil {
+		dbConfig, err := db.GetConfig()
+		if err != nil {
+			opt.Logger.Fatal("failed to load database configuration", logger.Error(err))
+		}
+		config.RegisterSection("database", dbConfig)
+		if opt.DB != nil {
+			dbConfig = withoutPrimary(dbConfig)
+		}
+		opt.DataSources, err = db.OpenDataSources(dbConfig)
+		if err != nil {
+			opt.Logger.Fatal("failed to open database", logger.Error(err))
+		}
+	}
+	if opt.DB == nil {
+		opt.DB = opt.DataSources.Primary()
+	} else if opt.DataSources.Primary() == nil {
+		opt.DataSources.Add(db.PrimaryDataSource, opt.DB)
+	}
+	db.DefaultDataSource = opt.DB
+
+	// Fill the Auditing fields of entities from the authenticated principal and
+	// isolate the rows of entities embedding entity.Tenanted by the context
+	// tenant, on every data source and tenant database.
+	plugins := []gorm.Plugin{&db.AuditingPlugin{Fallback: opt.AuditActor}, &tenancy.Plugin{Strict: opt.StrictTenancy}}
+	databases := []*gorm.DB{opt.DB}
+	for _, name := range opt.DataSources.Names() {
+		ds, _ := opt.DataSources.Get(name)
+		databases = append(databases, ds)
+	}
+	for _, database := range databases {
+		if database == nil {
+			continue
+		}
+		for _, plugin := range plugins {
+			if err := database.Use(plugin); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+				opt.Logger.Error("Failed to register database plugin", logger.String("plugin", plugin.Name()), logger.Error(err))
+			}
+		}
+	}
+	if opt.Tenants != nil {
+		if strategy, ok := opt.Tenants.Strategy().(*tenancy.DatabaseStrategy); ok {
+			if err := strategy.Registry().Use(plugins...); err != nil {
+				opt.Logger.Error("Failed to register database plugins on tenant databases", logger.Error(err))
+			}
+		}
 	}
 
 	// Initialize EventBus if not provided
@@ -74,6 +222,7 @@ func newOptions(opts ...Option) *Options {
 		opt.http = http.NewHTTPServer(
 			http.WithAddress(fmt.Sprintf(":%s", cfg.Server.Port)),
 			http.WithMode(webMode),
+			http.WithMiddleware(http.RequestContext(opt.Logger)),
 		)
 	}
 
@@ -83,8 +232,16 @@ func newOptions(opts ...Option) *Options {
 		if err != nil {
 			opt.Logger.Fatal("failed to create grpc server", logger.Error(err))
 		}
+		config.RegisterSection("grpc", grpcConfig)
 		if grpcConfig.Grpc.Enabled {
-			opt.GRPCServer = grpc.NewGRPCServer(grpc.WithAddress(grpcConfig.Grpc.Address))
+			grpcOpts := []grpc.Option{
+				grpc.WithAddress(grpcConfig.Grpc.Address),
+				grpc.WithLogger(opt.Logger),
+			}
+			if opt.AuthManager != nil {
+				grpcOpts = append(grpcOpts, grpc.WithAuthManager(opt.AuthManager))
+			}
+			opt.GRPCServer = grpc.NewGRPCServer(grpcOpts...)
 		}
 	}
 
@@ -126,11 +283,103 @@ func WithGRPCServer(grpcServer grpc.GRPCServer) Option {
 	return func(o *Options) { o.GRPCServer = grpcServer }
 }
 
-// WithDB sets the DB dependency.
+// WithDataSources sets the named data sources instead of opening those
+// configured in application.yaml. Their primary is used as DB unless WithDB is given.
+func WithDataSources(d *db.DataSources) Option {
+	return func(o *Options) { o.DataSources = d }
+}
+
+// WithDB sets the primary database in place of the one configured in
+// application.yaml. The other configured data sources, e.g. the replica, are
+// still opened unless WithDataSources is given.
 func WithDB(db *gorm.DB) Option {
 	return func(o *Options) { o.DB = db }
 }
 
+// WithAuth sets the manager authenticating bearer tokens, used by the HTTP
+// auth middleware and for every call of the default gRPC server.
 func WithAuth(authManager auth.AuthManager) Option {
 	return func(o *Options) { o.AuthManager = authManager }
 }
+
+// WithLogLevels sets the level state used by the default logger.
+func WithLogLevels(levels *logger.Levels) Option {
+	return func(o *Options) { o.LogLevels = levels }
+}
+
+// WithLogLevelEndpoint exposes the log levels on the HTTP server at path,
+// e.g. "/admin/log/level", behind middleware such as authentication. The
+// endpoint is only mounted when the levels drive the application logger.
+func WithLogLevelEndpoint(path string, middleware ...http.HandlerFunc) Option {
+	return func(o *Options) {
+		o.LogLevelPath = path
+		o.LogLevelMiddleware = middleware
+	}
+}
+
+// WithCursorKey sets the key signing the pagination cursors of module
+// repositories, repository.cursorKey from config by default.
+func WithCursorKey(key []byte) Option {
+	return func(o *Options) { o.CursorKey = key }
+}
+
+// WithAuditActor sets the actor recorded in CreatedBy/UpdatedBy when no
+// principal is authenticated, db.DefaultAuditActor by default.
+func WithAuditActor(actor string) Option {
+	return func(o *Options) { o.AuditActor = actor }
+}
+
+// WithStrictTenancy rejects queries on entities embedding entity.Tenanted that
+// run without a tenant in their context; see tenancy.Plugin.
+func WithStrictTenancy() Option {
+	return func(o *Options) { o.StrictTenancy = true }
+}
+
+// WithTenantManager sets the manager applying the tenant migrations of modules
+// implementing tenancy.MigrationSource.
+func WithTenantManager(m *tenancy.Manager) Option {
+	return func(o *Options) { o.Tenants = m }
+}
+
+// WithTenantEndpoint exposes the tenant manager on the HTTP server at path,
+// e.g. "/admin/tenants", behind middleware such as authentication. As it
+// provisions and drops tenants, it is not registered without middleware.
+func WithTenantEndpoint(path string, middleware ...http.HandlerFunc) Option {
+	return func(o *Options) {
+		o.TenantPath = path
+		o.TenantMiddleware = middleware
+	}
+}
+
+// withoutPrimary returns a copy of cfg without the primary data source.
+func withoutPrimary(cfg *db.Config) *db.Config {
+	named := make(map[string]db.DataSourceConfig, len(cfg.DataSources))
+	for name, ds := range cfg.DataSources {
+		if name != db.PrimaryDataSource {
+			named[name] = ds
+		}
+	}
+	return &db.Config{DataSources: named}
+}
+
+// newLogRedactor creates the redactor of the default logger from config.
+func newLogRedactor(cfg *config.Config) *logger.Redactor {
+	options := logger.RedactOptions{
+		Keys:    append(append([]string(nil), logger.DefaultRedactKeys...), cfg.Log.Redact.Keys...),
+		HashKey: []byte(cfg.Log.Redact.HashKey),
+	}
+	if cfg.Log.Redact.Mode == "hash" {
+		options.Mode = logger.RedactHash
+	}
+	return logger.NewRedactor(options)
+}
+
+// newLogLevels creates the level state from config: the environment default
+// with the configured level spec applied on top.
+func newLogLevels(cfg *config.Config) (*logger.Levels, error) {
+	levels := logger.NewLevels(logger.DefaultLevel(cfg.Env))
+	if err := levels.Apply(cfg.Log.Level); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
@@ -0,0 +1,243 @@
+// Package audit records the change history of entities saved through a
+// repository.CrudRepository.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/ebrickdev/ebrick/messaging"
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Action is the kind of change recorded.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionRestore records the undeletion of a soft-deleted entity.
+	ActionRestore Action = "restore"
+	// ActionPurge records the permanent removal of an entity by HardDelete.
+	ActionPurge Action = "purge"
+)
+
+// DefaultTopic is the EventBus topic changes are published on.
+const DefaultTopic = "ebrick.audit"
+
+// DefaultSource is the CloudEvent source of published changes.
+const DefaultSource = "ebrick/audit"
+
+// Change holds the old and new value of a field; Old is nil on create and New on delete.
+type Change struct {
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+// Record is a row of the audit trail.
+type Record struct {
+	ID         uint64    `gorm:"primaryKey" json:"id"`
+	EntityType string    `gorm:"type:varchar(255);index:idx_audit_entity" json:"entity_type"`
+	EntityID   string    `gorm:"type:varchar(255);index:idx_audit_entity" json:"entity_id"`
+	Action     Action    `gorm:"type:varchar(16)" json:"action"`
+	Actor      string    `gorm:"type:varchar(255)" json:"actor"`
+	Timestamp  time.Time `gorm:"index" json:"timestamp"`
+	// Changes is the JSON object of changed fields, keyed by their JSON name, with Change values.
+	Changes string `gorm:"type:text" json:"changes"`
+}
+
+// TableName implements gorm's tabler.
+func (Record) TableName() string {
+	return "audit_records"
+}
+
+// Diff decodes Changes.
+func (r *Record) Diff() (map[string]Change, error) {
+	diff := map[string]Change{}
+	if r.Changes == "" {
+		return diff, nil
+	}
+	err := json.Unmarshal([]byte(r.Changes), &diff)
+	return diff, err
+}
+
+// Options configures a Trail.
+type Options struct {
+	// EventBus publishes every record as a CloudEvent when set.
+	EventBus messaging.EventBus
+	Topic    string
+	Source   string
+	// Actor returns who made a change; by default the ID of the auth.Principal
+	// in the context, or db.DefaultAuditActor.
+	Actor func(ctx context.Context) string
+}
+
+type Option func(*Options)
+
+// WithEventBus publishes records as CloudEvents on bus.
+func WithEventBus(bus messaging.EventBus) Option {
+	return func(o *Options) {
+		o.EventBus = bus
+	}
+}
+
+// WithTopic sets the topic records are published on.
+func WithTopic(topic string) Option {
+	return func(o *Options) {
+		o.Topic = topic
+	}
+}
+
+// WithSource sets the CloudEvent source of published records.
+func WithSource(source string) Option {
+	return func(o *Options) {
+		o.Source = source
+	}
+}
+
+// WithActor sets the function that resolves the actor of a change.
+func WithActor(actor func(ctx context.Context) string) Option {
+	return func(o *Options) {
+		o.Actor = actor
+	}
+}
+
+// Trail stores audit records and answers history queries.
+type Trail struct {
+	db      *gorm.DB
+	options Options
+}
+
+// NewTrail creates a Trail that stores records in db.
+func NewTrail(db *gorm.DB, opts ...Option) *Trail {
+	options := Options{Topic: DefaultTopic, Source: DefaultSource, Actor: principalActor}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Trail{db: db, options: options}
+}
+
+// AutoMigrate creates or updates the audit_records table.
+func (t *Trail) AutoMigrate(ctx context.Context) error {
+	return t.db.WithContext(ctx).AutoMigrate(&Record{})
+}
+
+// History returns the records of an entity, oldest first.
+func (t *Trail) History(ctx context.Context, entityType, entityID string) ([]Record, error) {
+	var records []Record
+	err := t.conn(ctx).
+		Where(&Record{EntityType: entityType, EntityID: entityID}).
+		Order("id").
+		Find(&records).Error
+	return records, err
+}
+
+// record stores a change between old and new, either of which may be nil. It
+// runs in the transaction carried by ctx, if any, and returns nil without
+// writing when nothing changed.
+func (t *Trail) record(ctx context.Context, action Action, entityType, entityID string, old, new any) (*Record, error) {
+	diff, err := diff(old, new)
+	if err != nil {
+		return nil, err
+	}
+	if len(diff) == 0 && action == ActionUpdate {
+		return nil, nil
+	}
+	changes, err := json.Marshal(diff)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Record{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      t.options.Actor(ctx),
+		Timestamp:  time.Now().UTC(),
+		Changes:    string(changes),
+	}
+	if err := t.conn(ctx).Create(rec).Error; err != nil {
+		return nil, fmt.Errorf("audit: write record: %w", err)
+	}
+	return rec, nil
+}
+
+// publish sends rec to the EventBus, if configured. It runs once the change
+// is committed, so failures are logged rather than returned.
+func (t *Trail) publish(ctx context.Context, rec *Record) {
+	if t.options.EventBus == nil || rec == nil {
+		return
+	}
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.NewString())
+	event.SetSource(t.options.Source)
+	event.SetType(t.options.Topic + "." + string(rec.Action))
+	event.SetSubject(rec.EntityType + "/" + rec.EntityID)
+	event.SetTime(rec.Timestamp)
+	err := event.SetData(cloudevents.ApplicationJSON, rec)
+	if err == nil {
+		err = t.options.EventBus.Publish(ctx, t.options.Topic, event)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to publish audit record", logger.Error(err))
+	}
+}
+
+func (t *Trail) conn(ctx context.Context) *gorm.DB {
+	return repository.DBFromContext(ctx, t.db)
+}
+
+// principalActor returns the ID of the authenticated principal or db.DefaultAuditActor.
+func principalActor(ctx context.Context) string {
+	if p, ok := auth.PrincipalFromContext(ctx); ok && p.GetID() != "" {
+		return p.GetID()
+	}
+	return db.DefaultAuditActor
+}
+
+// diff compares the JSON representations of old and new field by field.
+func diff(old, new any) (map[string]Change, error) {
+	before, err := fields(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := fields(new)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]Change{}
+	for k, v := range after {
+		if o, ok := before[k]; !ok || !reflect.DeepEqual(o, v) {
+			changes[k] = Change{Old: before[k], New: v}
+		}
+	}
+	for k, v := range before {
+		if _, ok := after[k]; !ok {
+			changes[k] = Change{Old: v}
+		}
+	}
+	return changes, nil
+}
+
+// fields returns the JSON object of v as a map; nil for a nil v.
+func fields(v any) (map[string]any, error) {
+	if rv := reflect.ValueOf(v); v == nil || rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/ebrickdev/ebrick/messaging"
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type principal struct{ id string }
+
+func (p principal) GetID() string                     { return p.id }
+func (p principal) GetEmail() string                  { return "" }
+func (p principal) GetRoles() []string                { return nil }
+func (p principal) GetClaims() map[string]interface{} { return nil }
+
+type account struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Owner   string    `json:"owner"`
+	Balance int       `json:"balance"`
+	Secret  string    `json:"-"`
+}
+
+func setup(t *testing.T, opts ...Option) (repository.CrudRepository[account, uuid.UUID], *Trail) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&account{}))
+
+	trail := NewTrail(db, opts...)
+	require.NoError(t, trail.AutoMigrate(context.Background()))
+	return NewRepository(repository.NewCrudRepository[account, uuid.UUID](db), db, trail), trail
+}
+
+func TestAuditedRepositoryRecordsHistory(t *testing.T) {
+	repo, trail := setup(t)
+	ctx := auth.ContextWithPrincipal(context.Background(), principal{id: "alice"})
+
+	acc, err := repo.Create(ctx, &account{ID: uuid.New(), Owner: "alice", Balance: 10, Secret: "s"})
+	require.NoError(t, err)
+
+	_, err = repo.Patch(ctx, &account{ID: acc.ID, Balance: 25})
+	require.NoError(t, err)
+
+	// Unchanged updates leave no record.
+	current, err := repo.FindByID(ctx, acc.ID)
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, current)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(context.Background(), acc.ID))
+
+	history, err := History[account](context.Background(), trail, acc.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	assert.Equal(t, ActionCreate, history[0].Action)
+	assert.Equal(t, "account", history[0].EntityType)
+	assert.Equal(t, acc.ID.String(), history[0].EntityID)
+	assert.Equal(t, "alice", history[0].Actor)
+	diff, err := history[0].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, Change{New: "alice"}, diff["owner"])
+	assert.NotContains(t, diff, "Secret")
+
+	assert.Equal(t, ActionUpdate, history[1].Action)
+	diff, err = history[1].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Change{"balance": {Old: float64(10), New: float64(25)}}, diff)
+
+	assert.Equal(t, ActionDelete, history[2].Action)
+	assert.Equal(t, "system", history[2].Actor)
+	diff, err = history[2].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, Change{Old: float64(25)}, diff["balance"])
+}
+
+func TestAuditedRepositoryRollsBackWithWrite(t *testing.T) {
+	repo, trail := setup(t, WithActor(func(context.Context) string { return "job" }))
+	ctx := context.Background()
+
+	acc, err := repo.Create(ctx, &account{ID: uuid.New(), Owner: "bob"})
+	require.NoError(t, err)
+	// Creating the same key again fails, and so does its record.
+	_, err = repo.Create(ctx, &account{ID: acc.ID, Owner: "eve"})
+	assert.Error(t, err)
+
+	history, err := trail.History(ctx, "account", acc.ID.String())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "job", history[0].Actor)
+}
+
+func TestAuditedRepositoryRecordsBulkWrites(t *testing.T) {
+	repo, trail := setup(t)
+	ctx := context.Background()
+	actions := func(id uuid.UUID) []Action {
+		history, err := trail.History(ctx, "account", id.String())
+		require.NoError(t, err)
+		out := make([]Action, len(history))
+		for i, rec := range history {
+			out[i] = rec.Action
+		}
+		return out
+	}
+
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, repo.CreateMany(ctx, []account{{ID: a, Owner: "a"}, {ID: b, Owner: "b"}}))
+	require.NoError(t, repo.UpsertMany(ctx, []account{{ID: a, Owner: "a", Balance: 5}, {ID: c, Owner: "c"}}, nil))
+
+	n, err := repo.UpdateWhere(ctx, repository.NewSpec(repository.In("owner", "b", "c")).Limit(1), map[string]any{"balance": 7})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = repo.DeleteWhere(ctx, repository.NewSpec(repository.Eq("owner", "a")))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = repo.DeleteMany(ctx, []uuid.UUID{b, uuid.New()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.Equal(t, []Action{ActionCreate, ActionUpdate, ActionDelete}, actions(a))
+	assert.Equal(t, []Action{ActionCreate, ActionUpdate, ActionDelete}, actions(b))
+	assert.Equal(t, []Action{ActionCreate, ActionUpdate}, actions(c))
+
+	history, err := trail.History(ctx, "account", c.String())
+	require.NoError(t, err)
+	diff, err := history[1].Diff()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Change{"balance": {Old: float64(0), New: float64(7)}}, diff)
+}
+
+func TestAuditedRepositoryPublishesEvents(t *testing.T) {
+	bus, err := messaging.NewMemoryEventBus()
+	require.NoError(t, err)
+	defer bus.Close()
+
+	events := make(chan cloudevents.Event, 1)
+	require.NoError(t, bus.Subscribe(DefaultTopic, func(_ context.Context, e cloudevents.Event) { events <- e }))
+
+	repo, _ := setup(t, WithEventBus(bus), WithSource("test"))
+	acc, err := repo.Create(context.Background(), &account{ID: uuid.New(), Owner: "carol"})
+	require.NoError(t, err)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, DefaultTopic+".create", e.Type())
+		assert.Equal(t, "test", e.Source())
+		assert.Equal(t, "account/"+acc.ID.String(), e.Subject())
+		var rec Record
+		require.NoError(t, json.Unmarshal(e.Data(), &rec))
+		assert.Equal(t, ActionCreate, rec.Action)
+	case <-time.After(time.Second):
+		t.Fatal("no audit event published")
+	}
+}
+
+func TestAuditedRepositoryPublishesAfterOuterCommit(t *testing.T) {
+	bus, err := messaging.NewMemoryEventBus()
+	require.NoError(t, err)
+	defer bus.Close()
+
+	events := make(chan cloudevents.Event, 2)
+	require.NoError(t, bus.Subscribe(DefaultTopic, func(_ context.Context, e cloudevents.Event) { events <- e }))
+
+	repo, trail := setup(t, WithEventBus(bus))
+	tx := repository.NewTxManager(trail.db)
+	ctx := context.Background()
+
+	// The write runs in a savepoint; the outer transaction rolls back.
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, &account{ID: uuid.New(), Owner: "dave"}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	require.EqualError(t, err, "abort")
+	select {
+	case e := <-events:
+		t.Fatalf("published %s for a rolled back write", e.Type())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := repo.Create(ctx, &account{ID: uuid.New(), Owner: "erin"})
+		assert.Empty(t, events, "published before the outer commit")
+		return err
+	})
+	require.NoError(t, err)
+	select {
+	case e := <-events:
+		assert.Equal(t, DefaultTopic+".create", e.Type())
+	case <-time.After(time.Second):
+		t.Fatal("no audit event published")
+	}
+}
@@ -0,0 +1,403 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ebrickdev/ebrick/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// auditedRepository records every write of the wrapped repository in a Trail.
+type auditedRepository[T any, ID comparable] struct {
+	repository.CrudRepository[T, ID]
+	db    *gorm.DB
+	trail *Trail
+	tx    repository.TxManager
+}
+
+// NewRepository wraps repo so that Create, Update, Patch, Delete, Restore,
+// HardDelete and the bulk writes also write an audit record per changed entity.
+// The write and its records share a transaction; db must be the database repo
+// uses. Changes made by Purge are not recorded.
+func NewRepository[T any, ID comparable](repo repository.CrudRepository[T, ID], db *gorm.DB, trail *Trail) repository.CrudRepository[T, ID] {
+	return &auditedRepository[T, ID]{
+		CrudRepository: repo,
+		db:             db,
+		trail:          trail,
+		tx:             repository.NewTxManager(db),
+	}
+}
+
+// History returns the records of the entity with the given primary key, oldest
+// first. id is a scalar key or, for composite keys, a key struct.
+func History[T any, ID comparable](ctx context.Context, trail *Trail, id ID) ([]Record, error) {
+	sch, err := parse[T](trail.db)
+	if err != nil {
+		return nil, err
+	}
+	return trail.History(ctx, sch.Name, formatKey(sch, id))
+}
+
+func (r *auditedRepository[T, ID]) Create(ctx context.Context, et *T) (*T, error) {
+	var created *T
+	err := r.within(ctx, func(ctx context.Context, sch *schema.Schema) (*Record, error) {
+		var err error
+		if created, err = r.CrudRepository.Create(ctx, et); err != nil {
+			return nil, err
+		}
+		return r.trail.record(ctx, ActionCreate, sch.Name, primaryKey(ctx, sch, created), nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *auditedRepository[T, ID]) Update(ctx context.Context, et *T) (*T, error) {
+	return r.update(ctx, et, r.CrudRepository.Update)
+}
+
+func (r *auditedRepository[T, ID]) Patch(ctx context.Context, et *T) (*T, error) {
+	return r.update(ctx, et, r.CrudRepository.Patch)
+}
+
+func (r *auditedRepository[T, ID]) update(ctx context.Context, et *T, write func(context.Context, *T) (*T, error)) (*T, error) {
+	var updated *T
+	err := r.within(ctx, func(ctx context.Context, sch *schema.Schema) (*Record, error) {
+		id := primaryKey(ctx, sch, et)
+		old, err := r.load(ctx, sch, et)
+		if err != nil {
+			return nil, err
+		}
+		if updated, err = write(ctx, et); err != nil {
+			return nil, err
+		}
+		// Reload, as Patch only carries the patched fields.
+		current, err := r.load(ctx, sch, et)
+		if err != nil {
+			return nil, err
+		}
+		return r.trail.record(ctx, ActionUpdate, sch.Name, id, old, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *auditedRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	err := r.within(ctx, func(ctx context.Context, sch *schema.Schema) (*Record, error) {
+		old, err := r.CrudRepository.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.CrudRepository.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+		return r.trail.record(ctx, ActionDelete, sch.Name, primaryKey(ctx, sch, old), old, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *auditedRepository[T, ID]) Restore(ctx context.Context, id ID) error {
+	err := r.within(ctx, func(ctx context.Context, sch *schema.Schema) (*Record, error) {
+		old, err := r.CrudRepository.FindByID(repository.IncludeDeleted(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.CrudRepository.Restore(ctx, id); err != nil {
+			return nil, err
+		}
+		current, err := r.CrudRepository.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return r.trail.record(ctx, ActionRestore, sch.Name, primaryKey(ctx, sch, old), old, current)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *auditedRepository[T, ID]) HardDelete(ctx context.Context, id ID) error {
+	err := r.within(ctx, func(ctx context.Context, sch *schema.Schema) (*Record, error) {
+		old, err := r.CrudRepository.FindByID(repository.IncludeDeleted(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.CrudRepository.HardDelete(ctx, id); err != nil {
+			return nil, err
+		}
+		return r.trail.record(ctx, ActionPurge, sch.Name, primaryKey(ctx, sch, old), old, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *auditedRepository[T, ID]) CreateMany(ctx context.Context, ets []T, opts ...repository.BulkOption) error {
+	err := r.withinAll(ctx, func(ctx context.Context, sch *schema.Schema) ([]*Record, error) {
+		if err := r.CrudRepository.CreateMany(ctx, ets, opts...); err != nil {
+			return nil, err
+		}
+		recs := make([]*Record, 0, len(ets))
+		for i := range ets {
+			rec, err := r.trail.record(ctx, ActionCreate, sch.Name, primaryKey(ctx, sch, &ets[i]), nil, &ets[i])
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+		return recs, nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *auditedRepository[T, ID]) UpsertMany(ctx context.Context, ets []T, conflictColumns []string, opts ...repository.BulkOption) error {
+	err := r.withinAll(ctx, func(ctx context.Context, sch *schema.Schema) ([]*Record, error) {
+		fields, err := conflictFields(sch, conflictColumns)
+		if err != nil {
+			return nil, err
+		}
+		olds := make([]*T, len(ets))
+		for i := range ets {
+			old, err := r.loadBy(ctx, fields, &ets[i])
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				olds[i] = old
+			}
+		}
+		if err := r.CrudRepository.UpsertMany(ctx, ets, conflictColumns, opts...); err != nil {
+			return nil, err
+		}
+		recs := make([]*Record, 0, len(ets))
+		for i := range ets {
+			current, err := r.loadBy(ctx, fields, &ets[i])
+			if err != nil {
+				return nil, err
+			}
+			var rec *Record
+			if olds[i] == nil {
+				rec, err = r.trail.record(ctx, ActionCreate, sch.Name, primaryKey(ctx, sch, current), nil, current)
+			} else {
+				rec, err = r.trail.record(ctx, ActionUpdate, sch.Name, primaryKey(ctx, sch, current), olds[i], current)
+			}
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+		return recs, nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *auditedRepository[T, ID]) UpdateWhere(ctx context.Context, spec *repository.Spec, fields map[string]any) (int64, error) {
+	var updated int64
+	err := r.withinAll(ctx, func(ctx context.Context, sch *schema.Schema) ([]*Record, error) {
+		olds, err := r.CrudRepository.FindBySpec(ctx, spec.Filter())
+		if err != nil {
+			return nil, err
+		}
+		if updated, err = r.CrudRepository.UpdateWhere(ctx, spec, fields); err != nil {
+			return nil, err
+		}
+		recs := make([]*Record, 0, len(olds))
+		for i := range olds {
+			current, err := r.loadBy(ctx, sch.PrimaryFields, &olds[i])
+			if err != nil {
+				return nil, err
+			}
+			rec, err := r.trail.record(ctx, ActionUpdate, sch.Name, primaryKey(ctx, sch, current), &olds[i], current)
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+		return recs, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+func (r *auditedRepository[T, ID]) DeleteWhere(ctx context.Context, spec *repository.Spec) (int64, error) {
+	var deleted int64
+	err := r.withinAll(ctx, func(ctx context.Context, sch *schema.Schema) ([]*Record, error) {
+		olds, err := r.CrudRepository.FindBySpec(ctx, spec.Filter())
+		if err != nil {
+			return nil, err
+		}
+		if deleted, err = r.CrudRepository.DeleteWhere(ctx, spec); err != nil {
+			return nil, err
+		}
+		return r.recordDeletes(ctx, sch, olds)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func (r *auditedRepository[T, ID]) DeleteMany(ctx context.Context, ids []ID, opts ...repository.BulkOption) (int64, error) {
+	var deleted int64
+	err := r.withinAll(ctx, func(ctx context.Context, sch *schema.Schema) ([]*Record, error) {
+		olds := make([]T, 0, len(ids))
+		for _, id := range ids {
+			old, err := r.CrudRepository.FindByID(ctx, id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			olds = append(olds, *old)
+		}
+		var err error
+		if deleted, err = r.CrudRepository.DeleteMany(ctx, ids, opts...); err != nil {
+			return nil, err
+		}
+		return r.recordDeletes(ctx, sch, olds)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// recordDeletes records the deletion of olds.
+func (r *auditedRepository[T, ID]) recordDeletes(ctx context.Context, sch *schema.Schema, olds []T) ([]*Record, error) {
+	recs := make([]*Record, 0, len(olds))
+	for i := range olds {
+		rec, err := r.trail.record(ctx, ActionDelete, sch.Name, primaryKey(ctx, sch, &olds[i]), &olds[i], nil)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// within runs fn in a transaction and publishes the record it wrote once the
+// transaction commits.
+func (r *auditedRepository[T, ID]) within(ctx context.Context, fn func(ctx context.Context, sch *schema.Schema) (*Record, error)) error {
+	return r.withinAll(ctx, func(ctx context.Context, sch *schema.Schema) ([]*Record, error) {
+		rec, err := fn(ctx, sch)
+		return []*Record{rec}, err
+	})
+}
+
+// withinAll runs fn in a transaction and publishes the records it wrote once
+// the transaction commits. When ctx carries a TxManager transaction, fn runs
+// in a savepoint and the records wait for the outer commit.
+func (r *auditedRepository[T, ID]) withinAll(ctx context.Context, fn func(ctx context.Context, sch *schema.Schema) ([]*Record, error)) error {
+	sch, err := parse[T](r.db)
+	if err != nil {
+		return err
+	}
+	return r.tx.WithinTx(ctx, func(txCtx context.Context) error {
+		recs, err := fn(txCtx, sch)
+		if err != nil {
+			return err
+		}
+		repository.AfterCommit(txCtx, func() {
+			for _, rec := range recs {
+				r.trail.publish(ctx, rec)
+			}
+		})
+		return nil
+	})
+}
+
+// load reads the stored state of the entity with et's primary key.
+func (r *auditedRepository[T, ID]) load(ctx context.Context, sch *schema.Schema, et *T) (*T, error) {
+	return r.loadBy(ctx, sch.PrimaryFields, et)
+}
+
+// loadBy reads the stored state of the entity whose fields equal et's.
+func (r *auditedRepository[T, ID]) loadBy(ctx context.Context, fields []*schema.Field, et *T) (*T, error) {
+	rv := reflect.ValueOf(et).Elem()
+	exprs := make([]clause.Expression, len(fields))
+	for i, f := range fields {
+		value, _ := f.ValueOf(ctx, rv)
+		exprs[i] = clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: value}
+	}
+	var stored T
+	err := repository.DBFromContext(ctx, r.db).Where(clause.And(exprs...)).First(&stored).Error
+	return &stored, err
+}
+
+// conflictFields returns the fields UpsertMany matches existing rows by: the
+// named columns or the primary key.
+func conflictFields(sch *schema.Schema, names []string) ([]*schema.Field, error) {
+	if len(names) == 0 {
+		return sch.PrimaryFields, nil
+	}
+	fields := make([]*schema.Field, len(names))
+	for i, name := range names {
+		f := sch.LookUpField(name)
+		if f == nil || f.DBName == "" {
+			return nil, fmt.Errorf("%w: %s.%s", repository.ErrUnknownField, sch.Name, name)
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
+
+// primaryKey returns et's primary key as text; the values of a composite key
+// are joined by commas in schema order.
+func primaryKey[T any](ctx context.Context, sch *schema.Schema, et *T) string {
+	rv := reflect.ValueOf(et).Elem()
+	values := make([]string, len(sch.PrimaryFields))
+	for i, pk := range sch.PrimaryFields {
+		value, _ := pk.ValueOf(ctx, rv)
+		values[i] = fmt.Sprint(value)
+	}
+	return strings.Join(values, ",")
+}
+
+// formatKey formats an ID like primaryKey formats the key of an entity.
+func formatKey(sch *schema.Schema, id any) string {
+	rv := reflect.ValueOf(id)
+	if _, valuer := id.(driver.Valuer); rv.Kind() != reflect.Struct || valuer || rv.Type() == reflect.TypeOf(time.Time{}) {
+		return fmt.Sprint(id)
+	}
+	values := make([]string, 0, len(sch.PrimaryFields))
+	for _, pk := range sch.PrimaryFields {
+		if f := rv.FieldByName(pk.Name); f.IsValid() {
+			values = append(values, fmt.Sprint(f.Interface()))
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// parse returns the GORM schema of T.
+func parse[T any](db *gorm.DB) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ebrickdev/ebrick/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultBatchSize is the number of rows written per statement by bulk operations.
+const DefaultBatchSize = 100
+
+// BulkOptions configures a bulk operation.
+type BulkOptions struct {
+	BatchSize int
+	// Progress is called after each batch with the number of rows processed so far and in total.
+	Progress func(done, total int)
+}
+
+type BulkOption func(*BulkOptions)
+
+// WithBatchSize sets the number of rows per statement.
+func WithBatchSize(size int) BulkOption {
+	return func(o *BulkOptions) {
+		o.BatchSize = size
+	}
+}
+
+// WithProgress sets the callback invoked after each batch.
+func WithProgress(progress func(done, total int)) BulkOption {
+	return func(o *BulkOptions) {
+		o.Progress = progress
+	}
+}
+
+func newBulkOptions(opts ...BulkOption) BulkOptions {
+	options := BulkOptions{BatchSize: DefaultBatchSize}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	return options
+}
+
+// RowError is the validation error of one row of a bulk operation.
+type RowError struct {
+	Index int
+	Err   error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// BulkValidationError lists the rows of a bulk operation that failed validation.
+// Nothing is written when it is returned.
+type BulkValidationError struct {
+	Rows []RowError
+}
+
+func (e *BulkValidationError) Error() string {
+	msgs := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		msgs[i] = row.Error()
+	}
+	return fmt.Sprintf("%d invalid rows: %s", len(e.Rows), strings.Join(msgs, "; "))
+}
+
+func (e *BulkValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Rows))
+	for i, row := range e.Rows {
+		errs[i] = row
+	}
+	return errs
+}
+
+func (r *crudRepository[T, ID]) CreateMany(ctx context.Context, ets []T, opts ...BulkOption) error {
+	if err := r.validateAll(ets); err != nil {
+		return err
+	}
+	return inBatches(ctx, r.db, ets, newBulkOptions(opts...), func(db *gorm.DB, batch []T) error {
+		return db.Create(&batch).Error
+	})
+}
+
+func (r *crudRepository[T, ID]) UpsertMany(ctx context.Context, ets []T, conflictColumns []string, opts ...BulkOption) error {
+	if err := r.validateAll(ets); err != nil {
+		return err
+	}
+	sch, err := r.schema()
+	if err != nil {
+		return err
+	}
+	onConflict := clause.OnConflict{UpdateAll: true}
+	for _, name := range conflictColumns {
+		col, err := column(sch, name)
+		if err != nil {
+			return err
+		}
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: col.Name})
+	}
+	var omit []string
+	if version, ok, err := r.versionColumn(sch); err != nil {
+		return err
+	} else if ok {
+		// Inserted rows start at the column default, 1; updated rows are bumped
+		// instead of taking the version of the entity.
+		omit = append(omit, version.Name)
+		onConflict.DoUpdates = clause.Assignments(map[string]any{version.Name: incremented(version)})
+	}
+	return inBatches(ctx, r.db, ets, newBulkOptions(opts...), func(db *gorm.DB, batch []T) error {
+		return db.Clauses(onConflict).Omit(omit...).Create(&batch).Error
+	})
+}
+
+func (r *crudRepository[T, ID]) UpdateWhere(ctx context.Context, spec *Spec, fields map[string]any) (int64, error) {
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	sch, err := r.schema()
+	if err != nil {
+		return 0, err
+	}
+	updates := make(map[string]any, len(fields))
+	for name, value := range fields {
+		col, err := column(sch, name)
+		if err != nil {
+			return 0, err
+		}
+		updates[col.Name] = value
+	}
+	if version, ok, err := r.versionColumn(sch); err != nil {
+		return 0, err
+	} else if _, set := updates[version.Name]; ok && !set {
+		updates[version.Name] = incremented(version)
+	}
+	query, err := spec.applyWhere(r.conn(ctx).Model(new(T)), sch)
+	if err != nil {
+		return 0, err
+	}
+	res := query.Updates(updates)
+	return res.RowsAffected, res.Error
+}
+
+func (r *crudRepository[T, ID]) DeleteWhere(ctx context.Context, spec *Spec) (int64, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return 0, err
+	}
+	query, err := spec.applyWhere(r.conn(ctx), sch)
+	if err != nil {
+		return 0, err
+	}
+	res := query.Delete(new(T))
+	return res.RowsAffected, res.Error
+}
+
+func (r *crudRepository[T, ID]) DeleteMany(ctx context.Context, ids []ID, opts ...BulkOption) (int64, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	err = inBatches(ctx, r.db, ids, newBulkOptions(opts...), func(db *gorm.DB, batch []ID) error {
+		cond, err := keysCondition(sch, batch)
+		if err != nil {
+			return err
+		}
+		res := db.Where(cond).Delete(new(T))
+		deleted += res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
+
+// validateAll validates every entity and collects the failures by index. It
+// also initializes the version of versioned entities, as Create does.
+func (r *crudRepository[T, ID]) validateAll(ets []T) error {
+	var rows []RowError
+	for i := range ets {
+		if err := r.validate.Struct(&ets[i]); err != nil {
+			rows = append(rows, RowError{Index: i, Err: err})
+		}
+		if v, ok := any(&ets[i]).(entity.Versionable); ok && v.GetVersion() == 0 {
+			v.SetVersion(1)
+		}
+	}
+	if len(rows) > 0 {
+		return &BulkValidationError{Rows: rows}
+	}
+	return nil
+}
+
+// versionColumn returns the version column when T embeds entity.Versioned.
+func (r *crudRepository[T, ID]) versionColumn(sch *schema.Schema) (clause.Column, bool, error) {
+	if _, ok := any(new(T)).(entity.Versionable); !ok {
+		return clause.Column{}, false, nil
+	}
+	col, err := column(sch, "Version")
+	return col, err == nil, err
+}
+
+// incremented returns the stored value of col plus one. col is qualified by
+// the table, as an upsert also sees the proposed row's columns.
+func incremented(col clause.Column) clause.Expr {
+	return gorm.Expr("? + 1", col)
+}
+
+// inBatches runs write for each batch of items in one transaction on db, or in
+// the transaction carried by ctx, reporting progress after each batch.
+func inBatches[E any](ctx context.Context, db *gorm.DB, items []E, options BulkOptions, write func(db *gorm.DB, batch []E) error) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+		tx := DBFromContext(ctx, db)
+		for start := 0; start < len(items); start += options.BatchSize {
+			end := min(start+options.BatchSize, len(items))
+			if err := write(tx, items[start:end]); err != nil {
+				return fmt.Errorf("batch at row %d: %w", start, err)
+			}
+			if options.Progress != nil {
+				options.Progress(end, len(items))
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bulkItem struct {
+	ID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Code  string    `gorm:"uniqueIndex" validate:"required"`
+	Price int       `validate:"gte=0"`
+}
+
+func newBulkRepo(t *testing.T) CrudRepository[bulkItem, uuid.UUID] {
+	t.Helper()
+	_, db := newSpecRepo(t)
+	require.NoError(t, db.AutoMigrate(&bulkItem{}))
+	return NewCrudRepository[bulkItem, uuid.UUID](db)
+}
+
+func bulkItems(codes ...string) []bulkItem {
+	items := make([]bulkItem, len(codes))
+	for i, code := range codes {
+		items[i] = bulkItem{ID: uuid.New(), Code: code, Price: i}
+	}
+	return items
+}
+
+func TestCreateManyBatchesWithProgress(t *testing.T) {
+	repo := newBulkRepo(t)
+	ctx := context.Background()
+
+	var progress [][2]int
+	err := repo.CreateMany(ctx, bulkItems("a", "b", "c", "d", "e"), WithBatchSize(2), WithProgress(func(done, total int) {
+		progress = append(progress, [2]int{done, total})
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{2, 5}, {4, 5}, {5, 5}}, progress)
+
+	count, err := repo.CountBySpec(ctx, NewSpec())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+}
+
+func TestCreateManyAggregatesValidationErrors(t *testing.T) {
+	repo := newBulkRepo(t)
+	ctx := context.Background()
+
+	items := bulkItems("a", "", "c", "")
+	items[2].Price = -1
+	err := repo.CreateMany(ctx, items)
+
+	var verr *BulkValidationError
+	require.True(t, errors.As(err, &verr))
+	indexes := make([]int, len(verr.Rows))
+	for i, row := range verr.Rows {
+		indexes[i] = row.Index
+	}
+	assert.Equal(t, []int{1, 2, 3}, indexes)
+
+	count, err := repo.CountBySpec(ctx, NewSpec())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestCreateManyRollsBackAllBatches(t *testing.T) {
+	repo := newBulkRepo(t)
+	ctx := context.Background()
+
+	err := repo.CreateMany(ctx, bulkItems("a", "b", "c", "a"), WithBatchSize(2))
+	assert.Error(t, err)
+
+	count, err := repo.CountBySpec(ctx, NewSpec())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestUpsertMany(t *testing.T) {
+	repo := newBulkRepo(t)
+	ctx := context.Background()
+	require.NoError(t, repo.CreateMany(ctx, bulkItems("a", "b")))
+
+	// New IDs but existing codes: rows are updated through the code conflict.
+	items := bulkItems("b", "c")
+	items[0].Price = 42
+	require.NoError(t, repo.UpsertMany(ctx, items, []string{"Code"}))
+
+	got, err := repo.FindBySpec(ctx, NewSpec().OrderBy("code"))
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, 42, got[1].Price)
+
+	// Without conflict columns the primary key is used.
+	got[0].Price = 7
+	require.NoError(t, repo.UpsertMany(ctx, got[:1], nil))
+	a, err := repo.FindBySpec(ctx, NewSpec(Eq("code", "a")))
+	require.NoError(t, err)
+	assert.Equal(t, 7, a[0].Price)
+
+	err = repo.UpsertMany(ctx, items, []string{"missing"})
+	assert.True(t, errors.Is(err, ErrUnknownField))
+}
+
+func TestUpdateWhereDeleteWhereDeleteMany(t *testing.T) {
+	repo := newBulkRepo(t)
+	ctx := context.Background()
+	items := bulkItems("a", "b", "c", "d", "e")
+	require.NoError(t, repo.CreateMany(ctx, items))
+
+	updated, err := repo.UpdateWhere(ctx, NewSpec(Gte("price", 3)), map[string]any{"Price": 100})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	_, err = repo.UpdateWhere(ctx, NewSpec(), map[string]any{"nope": 1})
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	deleted, err := repo.DeleteWhere(ctx, NewSpec(Eq("price", 100)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	deleted, err = repo.DeleteMany(ctx, []uuid.UUID{items[0].ID, items[1].ID, uuid.New()}, WithBatchSize(2))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	left, err := repo.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, left, 1)
+	assert.Equal(t, "c", left[0].Code)
+}
@@ -0,0 +1,414 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ebrickdev/ebrick/cache"
+	"github.com/ebrickdev/ebrick/cache/store"
+	"github.com/ebrickdev/ebrick/logger"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultCacheTTL is the lifetime of cached entities unless configured otherwise.
+const DefaultCacheTTL = 5 * time.Minute
+
+// CacheOptions configures a cached repository.
+type CacheOptions struct {
+	// TTL is the lifetime of cached entries.
+	TTL time.Duration
+	// Tags are added to every entry, next to the entity type tag.
+	Tags []string
+	// Prefix is prepended to cache keys and tags; "repository" by default.
+	Prefix string
+	// Scope returns the partition of the entries a context reads and writes,
+	// e.g. its tenant; entries are shared when it returns "".
+	Scope func(ctx context.Context) string
+}
+
+type CacheOption func(*CacheOptions)
+
+// WithCacheTTL sets the lifetime of cached entries.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.TTL = ttl
+	}
+}
+
+// WithCacheTags adds tags to every cached entry.
+func WithCacheTags(tags ...string) CacheOption {
+	return func(o *CacheOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// WithCacheScope partitions the entries by the value scope returns for the
+// context of each call, e.g. the tenant ID, so that results read in one
+// partition are not served in another.
+func WithCacheScope(scope func(ctx context.Context) string) CacheOption {
+	return func(o *CacheOptions) {
+		o.Scope = scope
+	}
+}
+
+// WithCachePrefix sets the prefix of cache keys and tags.
+func WithCachePrefix(prefix string) CacheOption {
+	return func(o *CacheOptions) {
+		o.Prefix = prefix
+	}
+}
+
+// cachedRepository serves reads of the wrapped repository from a cache.
+type cachedRepository[T any, ID comparable] struct {
+	CrudRepository[T, ID]
+	cache   cache.Cache
+	options CacheOptions
+	name    string
+}
+
+// WithCache wraps repo so that FindByID, FindBySpec, CountBySpec and
+// ExistsBySpec are served from c. Entries are tagged with the entity type, and
+// every write through the returned repository invalidates that tag, as a
+// change can affect any cached spec result; inside a transaction the tag is
+// invalidated once it commits. Misses are read from the primary database, and
+// reads under ForcePrimary skip the cache. Entities are cached by the columns
+// GORM maps. Entries are shared by all callers unless partitioned with
+// WithCacheScope. Writes made elsewhere are only seen once entries expire.
+// repo is returned unchanged when c is nil.
+func WithCache[T any, ID comparable](repo CrudRepository[T, ID], c cache.Cache, opts ...CacheOption) CrudRepository[T, ID] {
+	if c == nil {
+		return repo
+	}
+	options := CacheOptions{TTL: DefaultCacheTTL, Prefix: "repository"}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &cachedRepository[T, ID]{
+		CrudRepository: repo,
+		cache:          c,
+		options:        options,
+		name:           cacheName(repo),
+	}
+}
+
+// cacheName names the entries of T after its package and table, honoring
+// TableName and, when repo is a CrudRepository of this package, the naming
+// strategy of its database, so that same-named types of different packages
+// do not share entries.
+func cacheName[T any, ID comparable](repo CrudRepository[T, ID]) string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	var (
+		sch *schema.Schema
+		err error
+	)
+	if r, ok := repo.(*crudRepository[T, ID]); ok {
+		sch, err = r.schema()
+	} else {
+		sch, err = schema.Parse(new(T), &entitySchemas, schema.NamingStrategy{})
+	}
+	if err != nil {
+		return t.String()
+	}
+	return t.PkgPath() + "." + sch.Table
+}
+
+func (r *cachedRepository[T, ID]) FindByID(ctx context.Context, id ID) (*T, error) {
+	key := r.key(ctx, "id", fmt.Sprint(id))
+	var rows entityRows[T]
+	if r.get(ctx, key, &rows) && len(rows) == 1 {
+		return &rows[0], nil
+	}
+	found, err := r.CrudRepository.FindByID(r.miss(ctx), id)
+	if err != nil {
+		return found, err
+	}
+	r.set(ctx, key, entityRows[T]{*found})
+	return found, nil
+}
+
+func (r *cachedRepository[T, ID]) FindBySpec(ctx context.Context, spec *Spec) ([]T, error) {
+	key := r.key(ctx, "find", spec.String())
+	var rows entityRows[T]
+	if r.get(ctx, key, &rows) {
+		return rows, nil
+	}
+	entities, err := r.CrudRepository.FindBySpec(r.miss(ctx), spec)
+	if err != nil {
+		return entities, err
+	}
+	r.set(ctx, key, entityRows[T](entities))
+	return entities, nil
+}
+
+func (r *cachedRepository[T, ID]) CountBySpec(ctx context.Context, spec *Spec) (int64, error) {
+	key := r.key(ctx, "count", spec.String())
+	var count int64
+	if r.get(ctx, key, &count) {
+		return count, nil
+	}
+	count, err := r.CrudRepository.CountBySpec(r.miss(ctx), spec)
+	if err != nil {
+		return count, err
+	}
+	r.set(ctx, key, count)
+	return count, nil
+}
+
+func (r *cachedRepository[T, ID]) ExistsBySpec(ctx context.Context, spec *Spec) (bool, error) {
+	key := r.key(ctx, "exists", spec.String())
+	var exists bool
+	if r.get(ctx, key, &exists) {
+		return exists, nil
+	}
+	exists, err := r.CrudRepository.ExistsBySpec(r.miss(ctx), spec)
+	if err != nil {
+		return exists, err
+	}
+	r.set(ctx, key, exists)
+	return exists, nil
+}
+
+func (r *cachedRepository[T, ID]) Create(ctx context.Context, et *T) (*T, error) {
+	created, err := r.CrudRepository.Create(ctx, et)
+	r.invalidate(ctx, err)
+	return created, err
+}
+
+func (r *cachedRepository[T, ID]) Update(ctx context.Context, et *T) (*T, error) {
+	updated, err := r.CrudRepository.Update(ctx, et)
+	r.invalidate(ctx, err)
+	return updated, err
+}
+
+func (r *cachedRepository[T, ID]) Patch(ctx context.Context, et *T) (*T, error) {
+	patched, err := r.CrudRepository.Patch(ctx, et)
+	r.invalidate(ctx, err)
+	return patched, err
+}
+
+func (r *cachedRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	err := r.CrudRepository.Delete(ctx, id)
+	r.invalidate(ctx, err)
+	return err
+}
+
+func (r *cachedRepository[T, ID]) Restore(ctx context.Context, id ID) error {
+	err := r.CrudRepository.Restore(ctx, id)
+	r.invalidate(ctx, err)
+	return err
+}
+
+func (r *cachedRepository[T, ID]) HardDelete(ctx context.Context, id ID) error {
+	err := r.CrudRepository.HardDelete(ctx, id)
+	r.invalidate(ctx, err)
+	return err
+}
+
+func (r *cachedRepository[T, ID]) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := r.CrudRepository.Purge(ctx, retention)
+	r.invalidate(ctx, err)
+	return purged, err
+}
+
+func (r *cachedRepository[T, ID]) CreateMany(ctx context.Context, ets []T, opts ...BulkOption) error {
+	err := r.CrudRepository.CreateMany(ctx, ets, opts...)
+	r.invalidate(ctx, err)
+	return err
+}
+
+func (r *cachedRepository[T, ID]) UpsertMany(ctx context.Context, ets []T, conflictColumns []string, opts ...BulkOption) error {
+	err := r.CrudRepository.UpsertMany(ctx, ets, conflictColumns, opts...)
+	r.invalidate(ctx, err)
+	return err
+}
+
+func (r *cachedRepository[T, ID]) UpdateWhere(ctx context.Context, spec *Spec, fields map[string]any) (int64, error) {
+	updated, err := r.CrudRepository.UpdateWhere(ctx, spec, fields)
+	r.invalidate(ctx, err)
+	return updated, err
+}
+
+func (r *cachedRepository[T, ID]) DeleteWhere(ctx context.Context, spec *Spec) (int64, error) {
+	deleted, err := r.CrudRepository.DeleteWhere(ctx, spec)
+	r.invalidate(ctx, err)
+	return deleted, err
+}
+
+func (r *cachedRepository[T, ID]) DeleteMany(ctx context.Context, ids []ID, opts ...BulkOption) (int64, error) {
+	deleted, err := r.CrudRepository.DeleteMany(ctx, ids, opts...)
+	r.invalidate(ctx, err)
+	return deleted, err
+}
+
+// bypass reports whether reads must skip the cache: inside a transaction, which
+// may hold uncommitted changes, when soft-deleted rows are included, under
+// ForcePrimary, which asks for the latest rows, and on a database from
+// ContextWithConn outside a scope, which may hold other rows.
+func (r *cachedRepository[T, ID]) bypass(ctx context.Context) bool {
+	_, inTx := TxFromContext(ctx)
+	_, onConn := ConnFromContext(ctx)
+	return inTx || includesDeleted(ctx) || forcesPrimary(ctx) || onConn && r.scope(ctx) == ""
+}
+
+// miss returns the context a cache miss is read with. Misses that are cached
+// are read from the primary, as a replica may still serve the rows a write
+// invalidated.
+func (r *cachedRepository[T, ID]) miss(ctx context.Context) context.Context {
+	if r.bypass(ctx) {
+		return ctx
+	}
+	return ForcePrimary(ctx)
+}
+
+// scope returns the partition of ctx, or "" for the shared entries.
+func (r *cachedRepository[T, ID]) scope(ctx context.Context) string {
+	if r.options.Scope == nil {
+		return ""
+	}
+	return r.options.Scope(ctx)
+}
+
+// partition returns the prefix of the keys of the entries of scope, which is
+// also the tag of a scope's entries.
+func (r *cachedRepository[T, ID]) partition(scope string) string {
+	if scope == "" {
+		return r.tag()
+	}
+	return fmt.Sprintf("%s@%q", r.tag(), scope)
+}
+
+func (r *cachedRepository[T, ID]) key(ctx context.Context, kind, value string) string {
+	return r.partition(r.scope(ctx)) + ":" + kind + ":" + value
+}
+
+// tag is the tag shared by all entries of the entity type.
+func (r *cachedRepository[T, ID]) tag() string {
+	return r.options.Prefix + ":" + r.name
+}
+
+// sharedTag is the tag of the entries outside any scope of a scoped repository.
+func (r *cachedRepository[T, ID]) sharedTag() string {
+	return r.tag() + ":shared"
+}
+
+// get decodes the entry stored under key into dest and reports whether it was found.
+func (r *cachedRepository[T, ID]) get(ctx context.Context, key string, dest any) bool {
+	if r.bypass(ctx) {
+		return false
+	}
+	value, err := r.cache.Get(ctx, key)
+	if err != nil || value == nil {
+		return false
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// set stores value under key as JSON, so any store can hold it.
+func (r *cachedRepository[T, ID]) set(ctx context.Context, key string, value any) {
+	if r.bypass(ctx) {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	tags := append([]string{r.tag()}, r.options.Tags...)
+	if scope := r.scope(ctx); scope != "" {
+		tags = append(tags, r.partition(scope))
+	} else if r.options.Scope != nil {
+		tags = append(tags, r.sharedTag())
+	}
+	if err := r.cache.Set(ctx, key, data, store.WithExpiration(r.options.TTL), store.WithTags(tags)); err != nil {
+		logger.FromContext(ctx).Debug("Failed to cache entity", logger.String("key", key), logger.Error(err))
+	}
+}
+
+// invalidate drops the entity type's entries after a successful write. Inside
+// a TxManager transaction they are dropped once it commits, as readers outside
+// the transaction would otherwise cache the old rows again in between. A write
+// in a scope drops the entries of the scope and the shared ones, which can
+// include its rows; other writes drop all entries.
+func (r *cachedRepository[T, ID]) invalidate(ctx context.Context, err error) {
+	if err != nil {
+		return
+	}
+	tags := []string{r.tag()}
+	if scope := r.scope(ctx); scope != "" {
+		tags = []string{r.partition(scope), r.sharedTag()}
+	}
+	AfterCommit(ctx, func() {
+		if err := r.cache.Invalidate(ctx, store.WithInvalidateTags(tags)); err != nil {
+			logger.FromContext(ctx).Error("Failed to invalidate cached entities", logger.Any("tags", tags), logger.Error(err))
+		}
+	})
+}
+
+// entityRows encodes entities by the columns GORM maps rather than their JSON
+// form, so fields hidden from JSON, e.g. with `json:"-"`, survive the cache.
+// Fields GORM does not map are not loaded from the database either.
+type entityRows[T any] []T
+
+// entitySchemas caches the schemas parsed by entityRows.
+var entitySchemas sync.Map
+
+func (rows entityRows[T]) MarshalJSON() ([]byte, error) {
+	sch, err := schema.Parse(new(T), &entitySchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	out := make([]map[string]any, len(rows))
+	for i := range rows {
+		rv := reflect.ValueOf(&rows[i]).Elem()
+		row := make(map[string]any, len(sch.DBNames))
+		for _, name := range sch.DBNames {
+			row[name], _ = sch.FieldsByDBName[name].ValueOf(ctx, rv)
+		}
+		out[i] = row
+	}
+	return json.Marshal(out)
+}
+
+func (rows *entityRows[T]) UnmarshalJSON(data []byte) error {
+	sch, err := schema.Parse(new(T), &entitySchemas, schema.NamingStrategy{})
+	if err != nil {
+		return err
+	}
+	var in []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	out := make(entityRows[T], len(in))
+	for i, row := range in {
+		rv := reflect.ValueOf(&out[i]).Elem()
+		for name, raw := range row {
+			field, ok := sch.FieldsByDBName[name]
+			if !ok {
+				return fmt.Errorf("unknown cached column %s.%s", sch.Name, name)
+			}
+			value := reflect.New(field.FieldType)
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return err
+			}
+			if err := field.Set(ctx, rv, value.Elem().Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	*rows = out
+	return nil
+}
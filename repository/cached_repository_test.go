@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ebrickdev/ebrick/cache"
+	"github.com/ebrickdev/ebrick/cache/store"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// tagStore is an in-memory store.Store supporting tags.
+type tagStore struct {
+	mu      sync.Mutex
+	entries map[any]any
+	tags    map[string][]any
+	ttls    map[any]time.Duration
+}
+
+func newTagStore() *tagStore {
+	return &tagStore{entries: map[any]any{}, tags: map[string][]any{}, ttls: map[any]time.Duration{}}
+}
+
+func (s *tagStore) Get(_ context.Context, key any) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.entries[key]
+	if !ok {
+		return nil, store.NotFoundWithCause(errors.New("missing"))
+	}
+	return v, nil
+}
+
+func (s *tagStore) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	v, err := s.Get(ctx, key)
+	return v, s.ttls[key], err
+}
+
+func (s *tagStore) Set(_ context.Context, key any, value any, options ...store.Option) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := store.ApplyOptions(options...)
+	s.entries[key] = value
+	s.ttls[key] = o.Expiration
+	for _, tag := range o.Tags {
+		s.tags[tag] = append(s.tags[tag], key)
+	}
+	return nil
+}
+
+func (s *tagStore) Delete(_ context.Context, key any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *tagStore) Invalidate(_ context.Context, options ...store.InvalidateOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range store.ApplyInvalidateOptions(options...).Tags {
+		for _, key := range s.tags[tag] {
+			delete(s.entries, key)
+		}
+		delete(s.tags, tag)
+	}
+	return nil
+}
+
+func (s *tagStore) Clear(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = map[any]any{}
+	return nil
+}
+
+func (s *tagStore) GetType() string { return "tags" }
+
+// specItemsTag is the tag of the cached specItem entries.
+const specItemsTag = "repository:github.com/ebrickdev/ebrick/repository.spec_items"
+
+func newCachedRepo(t *testing.T, opts ...CacheOption) (CrudRepository[specItem, uuid.UUID], *gorm.DB, *tagStore) {
+	t.Helper()
+	repo, db := newSpecRepo(t)
+	s := newTagStore()
+	return WithCache(repo, cache.New(s), opts...), db, s
+}
+
+func TestCachedRepositoryServesReadsFromCache(t *testing.T) {
+	repo, db, s := newCachedRepo(t, WithCacheTTL(time.Minute), WithCacheTags("catalog"))
+	ctx := context.Background()
+
+	apple, err := repo.FindBySpec(ctx, NewSpec(Eq("name", "apple")))
+	require.NoError(t, err)
+	require.Len(t, apple, 1)
+	found, err := repo.FindByID(ctx, apple[0].ID)
+	require.NoError(t, err)
+	count, err := repo.CountBySpec(ctx, NewSpec(Gte("price", 20)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// Changes made behind the repository's back are not seen until invalidation.
+	require.NoError(t, db.Model(&specItem{}).Where("1 = 1").Update("name", "changed").Error)
+	require.NoError(t, db.Where("price = ?", 40).Delete(&specItem{}).Error)
+
+	cached, err := repo.FindByID(ctx, apple[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, found, cached)
+	again, err := repo.FindBySpec(ctx, NewSpec(Eq("name", "apple")))
+	require.NoError(t, err)
+	assert.Equal(t, apple, again)
+	count, err = repo.CountBySpec(ctx, NewSpec(Gte("price", 20)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	key := specItemsTag + ":id:" + apple[0].ID.String()
+	assert.Equal(t, time.Minute, s.ttls[key])
+	assert.Contains(t, s.tags["catalog"], key)
+	assert.Contains(t, s.tags[specItemsTag], key)
+}
+
+func TestCachedRepositoryInvalidatesOnWrite(t *testing.T) {
+	repo, _, _ := newCachedRepo(t)
+	ctx := context.Background()
+
+	items, err := repo.FindBySpec(ctx, NewSpec(Eq("name", "apple")))
+	require.NoError(t, err)
+	apple := items[0]
+	_, err = repo.FindByID(ctx, apple.ID)
+	require.NoError(t, err)
+
+	apple.Price = 15
+	_, err = repo.Update(ctx, &apple)
+	require.NoError(t, err)
+	found, err := repo.FindByID(ctx, apple.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15, found.Price)
+
+	found.Name = "green apple"
+	_, err = repo.Patch(ctx, found)
+	require.NoError(t, err)
+	exists, err := repo.ExistsBySpec(ctx, NewSpec(Eq("name", "green apple")))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, repo.Delete(ctx, apple.ID))
+	_, err = repo.FindByID(ctx, apple.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	exists, err = repo.ExistsBySpec(ctx, NewSpec(Eq("name", "green apple")))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCachedRepositoryBypassesCacheInTx(t *testing.T) {
+	repo, db, s := newCachedRepo(t)
+
+	err := NewTxManager(db).WithinTx(context.Background(), func(ctx context.Context) error {
+		_, err := repo.FindBySpec(ctx, NewSpec(Eq("name", "apple")))
+		return err
+	})
+	require.NoError(t, err)
+	assert.Empty(t, s.entries)
+}
+
+func TestCachedRepositoryWithReplica(t *testing.T) {
+	_, primary := newSpecRepo(t)
+	s := newTagStore()
+	repo := WithCache(NewCrudRepository[specItem, uuid.UUID](primary, WithReadDB(newReplica(t))), cache.New(s))
+	ctx := context.Background()
+
+	// Misses are read from the primary, never caching the lagging replica.
+	all, err := repo.FindBySpec(ctx, NewSpec().OrderBy("price"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apple", "banana", "cherry", "apricot"}, names(all))
+	apple := all[0]
+	apple.Price = 15
+	_, err = repo.Update(ctx, &apple)
+	require.NoError(t, err)
+	found, err := repo.FindByID(ctx, apple.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15, found.Price)
+
+	// ForcePrimary reads skip the cache, in both directions.
+	require.NoError(t, primary.Model(&specItem{}).Where("id = ?", apple.ID).Update("price", 16).Error)
+	found, err = repo.FindByID(ForcePrimary(ctx), apple.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 16, found.Price)
+	found, err = repo.FindByID(ctx, apple.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15, found.Price, "the cached entry is kept")
+	_, err = repo.CountBySpec(ForcePrimary(ctx), NewSpec())
+	require.NoError(t, err)
+	assert.NotContains(t, s.entries, specItemsTag+":count:"+NewSpec().String())
+}
+
+func TestWithCacheNil(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+	assert.Same(t, repo, WithCache(repo, nil))
+}
+
+func TestCachedRepositoryInvalidatesAfterCommit(t *testing.T) {
+	repo, db, s := newCachedRepo(t)
+	ctx := context.Background()
+
+	items, err := repo.FindBySpec(ctx, NewSpec(Eq("name", "apple")))
+	require.NoError(t, err)
+	key := specItemsTag + ":find:" + NewSpec(Eq("name", "apple")).String()
+
+	boom := errors.New("boom")
+	err = NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+		apple := items[0]
+		apple.Price = 11
+		if _, err := repo.Update(ctx, &apple); err != nil {
+			return err
+		}
+		assert.Contains(t, s.entries, key, "invalidated before commit")
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Contains(t, s.entries, key, "invalidated after rollback")
+
+	err = NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+		apple := items[0]
+		apple.Price = 12
+		_, err := repo.Update(ctx, &apple)
+		return err
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, s.entries, key)
+}
+
+type secretItem struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name   string    `json:"name"`
+	Secret string    `json:"-"`
+}
+
+func TestCachedRepositoryKeepsFieldsHiddenFromJSON(t *testing.T) {
+	_, db := newSpecRepo(t)
+	require.NoError(t, db.AutoMigrate(&secretItem{}))
+	repo := WithCache(NewCrudRepository[secretItem, uuid.UUID](db), cache.New(newTagStore()))
+	ctx := context.Background()
+
+	item, err := repo.Create(ctx, &secretItem{ID: uuid.New(), Name: "a", Secret: "s"})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		found, err := repo.FindByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, item, found)
+		all, err := repo.FindBySpec(ctx, NewSpec())
+		require.NoError(t, err)
+		assert.Equal(t, []secretItem{*item}, all)
+	}
+}
+
+type namedItem struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+func (namedItem) TableName() string { return "catalog_entries" }
+
+func TestCachedRepositoryNamesEntriesByPackageAndTable(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger:         logger.Discard,
+		NamingStrategy: schema.NamingStrategy{TablePrefix: "shop_"},
+	})
+	require.NoError(t, err)
+
+	named := WithCache(NewCrudRepository[namedItem, uuid.UUID](db), cache.New(newTagStore())).(*cachedRepository[namedItem, uuid.UUID])
+	assert.Equal(t, "github.com/ebrickdev/ebrick/repository.catalog_entries", named.name)
+	spec := WithCache(NewCrudRepository[specItem, uuid.UUID](db), cache.New(newTagStore())).(*cachedRepository[specItem, uuid.UUID])
+	assert.Equal(t, "github.com/ebrickdev/ebrick/repository.shop_spec_items", spec.name)
+}
+
+type scopeKey struct{}
+
+func TestCachedRepositoryScopes(t *testing.T) {
+	scope := func(ctx context.Context) string {
+		scope, _ := ctx.Value(scopeKey{}).(string)
+		return scope
+	}
+	repo, db, s := newCachedRepo(t, WithCacheScope(scope))
+	acme := context.WithValue(context.Background(), scopeKey{}, "acme")
+	globex := context.WithValue(context.Background(), scopeKey{}, "globex")
+	spec := NewSpec(Eq("name", "apple"))
+
+	count, err := repo.CountBySpec(acme, spec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	_, err = repo.CountBySpec(context.Background(), spec)
+	require.NoError(t, err)
+	assert.Contains(t, s.entries, specItemsTag+`@"acme":count:`+spec.String())
+
+	// Each scope reads its own entries.
+	require.NoError(t, db.Model(&specItem{}).Where("name = ?", "apple").Update("name", "pear").Error)
+	count, err = repo.CountBySpec(globex, spec)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	count, err = repo.CountBySpec(acme, spec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// A write in a scope drops the entries of the scope and the shared ones.
+	_, err = repo.Create(globex, &specItem{ID: uuid.New(), Name: "fig"})
+	require.NoError(t, err)
+	assert.NotContains(t, s.entries, specItemsTag+`@"globex":count:`+spec.String())
+	assert.NotContains(t, s.entries, specItemsTag+":count:"+spec.String())
+	assert.Contains(t, s.entries, specItemsTag+`@"acme":count:`+spec.String())
+
+	// Reads on another database outside a scope skip the cache.
+	_, err = repo.CountBySpec(ContextWithConn(context.Background(), db), spec)
+	require.NoError(t, err)
+	assert.NotContains(t, s.entries, specItemsTag+":count:"+spec.String())
+}
@@ -3,21 +3,44 @@ package repository
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"time"
 
+	"github.com/ebrickdev/ebrick/entity"
 	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// ErrConcurrentModification is returned by Update and Patch of an entity
+// embedding entity.Versioned when the row was changed or deleted since the
+// entity was loaded.
+var ErrConcurrentModification error = concurrentModificationError{}
+
+// concurrentModificationError lets transports recognise ErrConcurrentModification
+// by its ConcurrentModification method without importing repository.
+type concurrentModificationError struct{}
+
+func (concurrentModificationError) Error() string { return "concurrent modification" }
+
+// ConcurrentModification reports that the error is a lost optimistic lock.
+func (concurrentModificationError) ConcurrentModification() bool { return true }
+
 // CrudRepository defines a set of generic CRUD operations.
 // Note: T is expected to be a struct type.
-type CrudRepository[T any] interface {
+//
+// ID is the type of the primary key, e.g. uuid.UUID, int64 or string. Entities
+// with a composite primary key use a key struct whose fields are named after
+// the primary key fields.
+type CrudRepository[T any, ID comparable] interface {
 	Create(ctx context.Context, et *T) (*T, error)
-	FindByID(ctx context.Context, id uuid.UUID) (*T, error)
+	FindByID(ctx context.Context, id ID) (*T, error)
 	Update(ctx context.Context, et *T) (*T, error)
 	Patch(ctx context.Context, et *T) (*T, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id ID) error
 	ListAll(ctx context.Context) ([]T, error)
 	First(ctx context.Context, et *T) (*T, error)
 	FindWithEntity(ctx context.Context, et *T) ([]T, error)
@@ -28,53 +51,106 @@ type CrudRepository[T any] interface {
 	Exists(ctx context.Context, conditions map[string]any) (bool, error)
 	// Add paging method
 	ListPaged(ctx context.Context, offset int, limit int) ([]T, int64, error)
+	// FindBySpec returns the entities matching the spec, sorted, projected and limited as it describes.
+	FindBySpec(ctx context.Context, spec *Spec) ([]T, error)
+	// CountBySpec counts the entities matching the spec's conditions.
+	CountBySpec(ctx context.Context, spec *Spec) (int64, error)
+	// ExistsBySpec reports whether any entity matches the spec's conditions.
+	ExistsBySpec(ctx context.Context, spec *Spec) (bool, error)
+	// ListCursor returns a keyset paginated page; see CursorRequest.
+	ListCursor(ctx context.Context, req CursorRequest) (*CursorPage[T], error)
+	// Restore undeletes a soft-deleted entity.
+	Restore(ctx context.Context, id ID) error
+	// HardDelete permanently removes an entity, whether soft-deleted or not.
+	HardDelete(ctx context.Context, id ID) error
+	// ListDeleted returns the soft-deleted entities.
+	ListDeleted(ctx context.Context) ([]T, error)
+	// Purge permanently removes entities soft-deleted longer ago than retention.
+	Purge(ctx context.Context, retention time.Duration) (int64, error)
+	// CreateMany validates all entities, then inserts them in batches in one
+	// transaction. Validation failures are returned as *BulkValidationError.
+	CreateMany(ctx context.Context, ets []T, opts ...BulkOption) error
+	// UpsertMany inserts the entities in batches, updating the existing rows that
+	// conflict on conflictColumns, or on the primary key when empty. The version
+	// of updated versioned rows is incremented and not read back.
+	UpsertMany(ctx context.Context, ets []T, conflictColumns []string, opts ...BulkOption) error
+	// UpdateWhere sets fields on every entity matching the spec's conditions,
+	// incrementing the version of versioned entities.
+	UpdateWhere(ctx context.Context, spec *Spec, fields map[string]any) (int64, error)
+	// DeleteWhere deletes every entity matching the spec's conditions.
+	DeleteWhere(ctx context.Context, spec *Spec) (int64, error)
+	// DeleteMany deletes the entities with the given IDs in batches in one transaction.
+	DeleteMany(ctx context.Context, ids []ID, opts ...BulkOption) (int64, error)
 }
 
 // NewCrudRepository creates a new CrudRepository instance for type T.
 // It also initializes a validator instance for reuse.
-func NewCrudRepository[T any](db *gorm.DB) CrudRepository[T] {
-	return &crudRepository[T]{
+func NewCrudRepository[T any, ID comparable](db *gorm.DB, opts ...Option) CrudRepository[T, ID] {
+	options := newOptions(opts...)
+	return &crudRepository[T, ID]{
 		db:       db,
+		readDB:   options.ReadDB,
 		validate: validator.New(),
+		cursors:  cursorCodec{key: options.CursorKey},
 	}
 }
 
-type crudRepository[T any] struct {
+type crudRepository[T any, ID comparable] struct {
 	db       *gorm.DB
+	readDB   *gorm.DB
 	validate *validator.Validate
+	cursors  cursorCodec
 }
 
-func (r *crudRepository[T]) Create(ctx context.Context, et *T) (*T, error) {
+func (r *crudRepository[T, ID]) Create(ctx context.Context, et *T) (*T, error) {
 	if err := r.validate.Struct(et); err != nil {
 		return nil, err
 	}
-	err := r.db.WithContext(ctx).Create(et).Error
+	if v, ok := any(et).(entity.Versionable); ok && v.GetVersion() == 0 {
+		v.SetVersion(1)
+	}
+	err := r.conn(ctx).Create(et).Error
 	return et, err
 }
 
-func (r *crudRepository[T]) FindByID(ctx context.Context, id uuid.UUID) (*T, error) {
+func (r *crudRepository[T, ID]) FindByID(ctx context.Context, id ID) (*T, error) {
 	var et T
-	err := r.db.WithContext(ctx).First(&et, id).Error
+	cond, err := r.keyCondition(id)
+	if err != nil {
+		return &et, err
+	}
+	err = r.reader(ctx).Where(cond).First(&et).Error
 	return &et, err
 }
 
-func (r *crudRepository[T]) Update(ctx context.Context, et *T) (*T, error) {
+func (r *crudRepository[T, ID]) Update(ctx context.Context, et *T) (*T, error) {
 	if err := r.validate.Struct(et); err != nil {
 		return nil, err
 	}
-	err := r.db.WithContext(ctx).Save(et).Error
+	if v, ok := any(et).(entity.Versionable); ok {
+		if err := r.updateVersioned(ctx, et, v, true); err != nil {
+			return nil, err
+		}
+		return et, nil
+	}
+	err := r.conn(ctx).Save(et).Error
 	return et, err
 }
 
-func (r *crudRepository[T]) Patch(ctx context.Context, et *T) (*T, error) {
+func (r *crudRepository[T, ID]) Patch(ctx context.Context, et *T) (*T, error) {
 	var updated T
-	err := r.db.WithContext(ctx).Model(et).Updates(et).Error
+	var err error
+	if v, ok := any(et).(entity.Versionable); ok {
+		err = r.updateVersioned(ctx, et, v, false)
+	} else {
+		err = r.conn(ctx).Model(et).Updates(et).Error
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Reload the updated entity from the DB.
-	err = r.db.WithContext(ctx).Model(et).First(&updated).Error
+	err = r.conn(ctx).Model(et).First(&updated).Error
 	if err != nil {
 		return nil, err
 	}
@@ -82,84 +158,128 @@ func (r *crudRepository[T]) Patch(ctx context.Context, et *T) (*T, error) {
 	return et, err
 }
 
-func (r *crudRepository[T]) Delete(ctx context.Context, id uuid.UUID) error {
-	var et T
-	return r.db.WithContext(ctx).Delete(&et, id).Error
+func (r *crudRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	cond, err := r.keyCondition(id)
+	if err != nil {
+		return err
+	}
+	return r.conn(ctx).Where(cond).Delete(new(T)).Error
 }
 
-func (r *crudRepository[T]) ListAll(ctx context.Context) ([]T, error) {
+func (r *crudRepository[T, ID]) ListAll(ctx context.Context) ([]T, error) {
 	var entities []T
-	err := r.db.WithContext(ctx).Find(&entities).Error
+	err := r.reader(ctx).Find(&entities).Error
 	return entities, err
 }
 
-func (r *crudRepository[T]) First(ctx context.Context, et *T) (*T, error) {
+func (r *crudRepository[T, ID]) First(ctx context.Context, et *T) (*T, error) {
 	var existed T
-	err := r.db.WithContext(ctx).Where(et).First(&existed).Error
+	err := r.reader(ctx).Where(et).First(&existed).Error
 	return &existed, err
 }
 
-func (r *crudRepository[T]) FindWithEntity(ctx context.Context, et *T) ([]T, error) {
+func (r *crudRepository[T, ID]) FindWithEntity(ctx context.Context, et *T) ([]T, error) {
 	var entities []T
-	err := r.db.WithContext(ctx).Where(et).Find(&entities).Error
+	err := r.reader(ctx).Where(et).Find(&entities).Error
 	return entities, err
 }
 
-func (r *crudRepository[T]) FindWithConditions(ctx context.Context, conditions map[string]any) ([]T, error) {
+func (r *crudRepository[T, ID]) FindWithConditions(ctx context.Context, conditions map[string]any) ([]T, error) {
+	db, err := r.withConditions(r.reader(ctx), conditions)
+	if err != nil {
+		return nil, err
+	}
 	var entities []T
-	err := r.db.WithContext(ctx).Where(conditions).Find(&entities).Error
+	err = db.Find(&entities).Error
 	return entities, err
 }
 
-func (r *crudRepository[T]) FindWithOrConditions(ctx context.Context, conditions map[string]any) ([]T, error) {
+// withConditions restricts db to the rows whose columns, Go field or column
+// names of T, equal the values of conditions.
+func (r *crudRepository[T, ID]) withConditions(db *gorm.DB, conditions map[string]any) (*gorm.DB, error) {
+	if len(conditions) == 0 {
+		return db, nil
+	}
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(conditions))
+	for key := range conditions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	exprs := make([]clause.Expression, 0, len(keys))
+	for _, key := range keys {
+		col, err := column(sch, key)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, clause.Eq{Column: col, Value: conditions[key]})
+	}
+	return db.Where(clause.And(exprs...)), nil
+}
+
+func (r *crudRepository[T, ID]) FindWithOrConditions(ctx context.Context, conditions map[string]any) ([]T, error) {
 	var entities []T
 	// Return an empty slice if no conditions are provided.
 	if len(conditions) == 0 {
 		return entities, nil
 	}
-	query := r.db.WithContext(ctx).Model(new(T))
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	exprs := make([]clause.Expression, 0, len(conditions))
 	for key, value := range conditions {
-		query = query.Or(fmt.Sprintf("%s = ?", key), value)
+		col, err := column(sch, key)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, clause.Eq{Column: col, Value: value})
 	}
-	err := query.Find(&entities).Error
+	err = r.reader(ctx).Where(clause.Or(exprs...)).Find(&entities).Error
 	return entities, err
 }
 
-func (r *crudRepository[T]) CountWithConditions(ctx context.Context, conditions map[string]any) (int64, error) {
+func (r *crudRepository[T, ID]) CountWithConditions(ctx context.Context, conditions map[string]any) (int64, error) {
+	db, err := r.withConditions(r.reader(ctx).Model(new(T)), conditions)
+	if err != nil {
+		return 0, err
+	}
 	var count int64
-	err := r.db.WithContext(ctx).Model(new(T)).Where(conditions).Count(&count).Error
+	err = db.Count(&count).Error
 	return count, err
 }
 
-func (r *crudRepository[T]) CountWithEntity(ctx context.Context, et *T) (int64, error) {
+func (r *crudRepository[T, ID]) CountWithEntity(ctx context.Context, et *T) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(new(T)).Where(et).Count(&count).Error
+	err := r.reader(ctx).Model(new(T)).Where(et).Count(&count).Error
 	return count, err
 }
 
-func (r *crudRepository[T]) Exists(ctx context.Context, conditions map[string]any) (bool, error) {
-	var count int64
-	err := r.db.WithContext(ctx).Model(new(T)).Where(conditions).Count(&count).Error
+func (r *crudRepository[T, ID]) Exists(ctx context.Context, conditions map[string]any) (bool, error) {
+	count, err := r.CountWithConditions(ctx, conditions)
 	return count > 0, err
 }
 
 // ListPaged returns a subset of entities based on offset and limit, along with the total count.
-func (r *crudRepository[T]) ListPaged(ctx context.Context, offset int, limit int) ([]T, int64, error) {
+func (r *crudRepository[T, ID]) ListPaged(ctx context.Context, offset int, limit int) ([]T, int64, error) {
 	var (
 		entities []T
 		total    int64
 	)
 
-	g, ctx := errgroup.WithContext(ctx)
+	g, ctx := newGroup(ctx)
 
 	// Run count query concurrently.
 	g.Go(func() error {
-		return r.db.WithContext(ctx).Model(new(T)).Count(&total).Error
+		return r.reader(ctx).Model(new(T)).Count(&total).Error
 	})
 
 	// Run paging query concurrently.
 	g.Go(func() error {
-		query := r.db.WithContext(ctx).Offset(offset)
+		query := r.reader(ctx).Offset(offset)
 		// Apply the limit only if it's greater than zero.
 		if limit > 0 {
 			query = query.Limit(limit)
@@ -173,3 +293,232 @@ func (r *crudRepository[T]) ListPaged(ctx context.Context, offset int, limit int
 
 	return entities, total, nil
 }
+
+func (r *crudRepository[T, ID]) FindBySpec(ctx context.Context, spec *Spec) ([]T, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	query, err := spec.apply(r.reader(ctx).Model(new(T)), sch)
+	if err != nil {
+		return nil, err
+	}
+	var entities []T
+	err = query.Find(&entities).Error
+	return entities, err
+}
+
+func (r *crudRepository[T, ID]) CountBySpec(ctx context.Context, spec *Spec) (int64, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return 0, err
+	}
+	query, err := spec.applyWhere(r.reader(ctx).Model(new(T)), sch)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = query.Count(&count).Error
+	return count, err
+}
+
+func (r *crudRepository[T, ID]) ExistsBySpec(ctx context.Context, spec *Spec) (bool, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return false, err
+	}
+	query, err := spec.applyWhere(r.reader(ctx).Model(new(T)), sch)
+	if err != nil {
+		return false, err
+	}
+	var found []map[string]any
+	err = query.Select("1").Limit(1).Find(&found).Error
+	return len(found) > 0, err
+}
+
+// ListCursor pages through the entities with keyset pagination. Unlike
+// ListPaged it seeks past the last seen sort key instead of using an offset, so
+// pages stay stable under concurrent inserts and no count is run unless asked.
+func (r *crudRepository[T, ID]) ListCursor(ctx context.Context, req CursorRequest) (*CursorPage[T], error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	ks, err := newKeyset(sch, req)
+	if err != nil {
+		return nil, err
+	}
+	var cur cursor
+	if req.Cursor != "" {
+		if cur, err = r.cursors.decode(req.Cursor); err != nil {
+			return nil, err
+		}
+		if cur.Table != ks.table || cur.SortBy != ks.sortBy || cur.Desc != ks.desc {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	page := &CursorPage[T]{}
+	g, gctx := newGroup(ctx)
+
+	if req.WithTotal {
+		g.Go(func() error {
+			var total int64
+			query, err := req.Filter.applyWhere(r.reader(gctx).Model(new(T)), sch)
+			if err != nil {
+				return err
+			}
+			if err := query.Count(&total).Error; err != nil {
+				return err
+			}
+			page.Total = &total
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		query, err := req.Filter.applyWhere(r.reader(gctx).Model(new(T)), sch)
+		if err != nil {
+			return err
+		}
+		if req.Cursor != "" {
+			if query, err = ks.seek(query, cur); err != nil {
+				return err
+			}
+		}
+		query = ks.order(query, cur.Backward)
+		// Fetch one extra row to learn whether another page follows.
+		if req.Limit > 0 {
+			query = query.Limit(req.Limit + 1)
+		}
+		return query.Find(&page.Items).Error
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	more := req.Limit > 0 && len(page.Items) > req.Limit
+	if more {
+		page.Items = page.Items[:req.Limit]
+	}
+	if cur.Backward {
+		for i, j := 0, len(page.Items)-1; i < j; i, j = i+1, j-1 {
+			page.Items[i], page.Items[j] = page.Items[j], page.Items[i]
+		}
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	// Walking forward, a previous page exists whenever we started from a cursor;
+	// walking backward, the page we came from always follows.
+	hasNext, hasPrev := more, req.Cursor != ""
+	if cur.Backward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		if page.Next, err = r.encodeCursor(ctx, ks, page.Items[len(page.Items)-1], false); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if page.Prev, err = r.encodeCursor(ctx, ks, page.Items[0], true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (r *crudRepository[T, ID]) encodeCursor(ctx context.Context, ks *keyset, item T, backward bool) (string, error) {
+	cur, err := ks.position(ctx, reflect.ValueOf(&item).Elem(), backward)
+	if err != nil {
+		return "", err
+	}
+	return r.cursors.encode(cur)
+}
+
+// updateVersioned updates et only if the stored version still equals its
+// version, and increments the version. all selects every field, as Save does;
+// otherwise only non-zero fields are written.
+func (r *crudRepository[T, ID]) updateVersioned(ctx context.Context, et *T, v entity.Versionable, all bool) error {
+	sch, err := r.schema()
+	if err != nil {
+		return err
+	}
+	col, err := column(sch, "Version")
+	if err != nil {
+		return err
+	}
+	// Without a primary key the version condition alone would match other rows.
+	if pk := sch.PrioritizedPrimaryField; pk == nil {
+		return gorm.ErrMissingWhereClause
+	} else if _, zero := pk.ValueOf(ctx, reflect.ValueOf(et).Elem()); zero {
+		return gorm.ErrMissingWhereClause
+	}
+
+	current := v.GetVersion()
+	v.SetVersion(current + 1)
+	query := r.conn(ctx).Model(et).Where(clause.Eq{Column: col, Value: current})
+	if all {
+		query = query.Select("*")
+	}
+	res := query.Updates(et)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = fmt.Errorf("%w: %s version %d", ErrConcurrentModification, sch.Name, current)
+	}
+	if res.Error != nil {
+		v.SetVersion(current)
+		return res.Error
+	}
+	return nil
+}
+
+// conn returns the transaction carried by ctx, or the repository's database.
+func (r *crudRepository[T, ID]) conn(ctx context.Context) *gorm.DB {
+	return DBFromContext(ctx, r.db)
+}
+
+// reader returns the database for read queries: the read database when one is
+// configured, unless ctx carries a transaction or a database, or comes from
+// ForcePrimary.
+func (r *crudRepository[T, ID]) reader(ctx context.Context) *gorm.DB {
+	if r.readDB == nil || forcesPrimary(ctx) {
+		return r.conn(ctx)
+	}
+	if _, ok := TxFromContext(ctx); ok {
+		return r.conn(ctx)
+	}
+	if _, ok := ConnFromContext(ctx); ok {
+		return r.conn(ctx)
+	}
+	return DBFromContext(ctx, r.readDB)
+}
+
+// newGroup returns an errgroup for concurrent queries. Queries of a transaction
+// share one connection, so they run one at a time.
+func newGroup(ctx context.Context) (*errgroup.Group, context.Context) {
+	g, gctx := errgroup.WithContext(ctx)
+	if _, ok := TxFromContext(ctx); ok {
+		g.SetLimit(1)
+	}
+	return g, gctx
+}
+
+// keyCondition returns the condition selecting the row with the given ID.
+func (r *crudRepository[T, ID]) keyCondition(id ID) (clause.Expression, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	return keyCondition(sch, id)
+}
+
+// schema returns the parsed GORM schema of T, used to validate field names.
+func (r *crudRepository[T, ID]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
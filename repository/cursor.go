@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed, was tampered with or
+// does not belong to the listed entity or the requested sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorRequest describes a page of a keyset paginated listing.
+type CursorRequest struct {
+	// Cursor is the opaque Next or Prev value of a previous page; empty for the first page.
+	Cursor string
+	// Limit is the page size; 0 or less returns all remaining rows.
+	Limit int
+	// SortBy is the field to sort by; the primary key when empty. The primary
+	// key is always added as a tie breaker, so SortBy need not be unique.
+	SortBy string
+	// Desc sorts in descending order.
+	Desc bool
+	// Filter restricts the listed rows; only its conditions are used.
+	Filter *Spec
+	// WithTotal counts the rows matching Filter. It costs a COUNT(*) per page.
+	WithTotal bool
+}
+
+// CursorPage is a page of a keyset paginated listing.
+type CursorPage[T any] struct {
+	Items []T
+	// Next is the cursor of the following page, empty on the last page.
+	Next string
+	// Prev is the cursor of the preceding page, empty on the first page.
+	Prev string
+	// Total is the number of matching rows, set only if requested.
+	Total *int64
+}
+
+// cursor is the signed payload of an opaque cursor. Table binds it to the
+// entity listed, since repositories may share the signing key.
+type cursor struct {
+	Table    string            `json:"t"`
+	SortBy   string            `json:"s"`
+	Desc     bool              `json:"d,omitempty"`
+	Backward bool              `json:"b,omitempty"`
+	Values   []json.RawMessage `json:"v"`
+}
+
+// cursorCodec encodes cursors as base64url(payload) "." base64url(hmac).
+type cursorCodec struct {
+	key []byte
+}
+
+func (c cursorCodec) encode(cur cursor) (string, error) {
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload)), nil
+}
+
+func (c cursorCodec) decode(s string) (cursor, error) {
+	var cur cursor
+	enc := base64.RawURLEncoding
+	data, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return cur, ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(data)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return cur, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return cur, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+func (c cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// keyset holds the resolved sort fields of a cursor listing: the sort key and,
+// unless it is the primary key itself, the primary key as tie breaker.
+type keyset struct {
+	table  string
+	sortBy string
+	desc   bool
+	fields []*schema.Field
+}
+
+func newKeyset(sch *schema.Schema, req CursorRequest) (*keyset, error) {
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return nil, fmt.Errorf("cursor pagination requires a single primary key on %s", sch.Name)
+	}
+	ks := &keyset{table: sch.Table, sortBy: pk.Name, desc: req.Desc, fields: []*schema.Field{pk}}
+	if req.SortBy != "" {
+		field := sch.LookUpField(req.SortBy)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%w: %s.%s", ErrUnknownField, sch.Name, req.SortBy)
+		}
+		ks.sortBy = field.Name
+		if field != pk {
+			ks.fields = []*schema.Field{field, pk}
+		}
+	}
+	return ks, nil
+}
+
+// order sorts db by the keyset, reversed when walking backward.
+func (k *keyset) order(db *gorm.DB, backward bool) *gorm.DB {
+	for i := range k.fields {
+		db = db.Order(clause.OrderByColumn{Column: k.column(i), Desc: k.desc != backward})
+	}
+	return db
+}
+
+// seek restricts db to the rows after, or before when walking backward, the cursor position:
+// (a > x) OR (a = x AND b > y) for ascending order.
+func (k *keyset) seek(db *gorm.DB, cur cursor) (*gorm.DB, error) {
+	if len(cur.Values) != len(k.fields) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]any, len(k.fields))
+	for i, f := range k.fields {
+		v := reflect.New(f.FieldType)
+		if err := json.Unmarshal(cur.Values[i], v.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = v.Elem().Interface()
+	}
+
+	after := k.desc == cur.Backward
+	var or []clause.Expression
+	for i := range k.fields {
+		var and []clause.Expression
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: k.column(j), Value: values[j]})
+		}
+		if after {
+			and = append(and, clause.Gt{Column: k.column(i), Value: values[i]})
+		} else {
+			and = append(and, clause.Lt{Column: k.column(i), Value: values[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return db.Where(clause.Or(or...)), nil
+}
+
+func (k *keyset) column(i int) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: k.fields[i].DBName}
+}
+
+// position returns the cursor pointing at item in the given direction.
+func (k *keyset) position(ctx context.Context, item reflect.Value, backward bool) (cursor, error) {
+	cur := cursor{Table: k.table, SortBy: k.sortBy, Desc: k.desc, Backward: backward}
+	for _, f := range k.fields {
+		v, _ := f.ValueOf(ctx, item)
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return cur, err
+		}
+		cur.Values = append(cur.Values, raw)
+	}
+	return cur, nil
+}
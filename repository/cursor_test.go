@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCursorWalksForwardAndBack(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+	ctx := context.Background()
+	req := CursorRequest{Limit: 2, SortBy: "price"}
+
+	first, err := repo.ListCursor(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apple", "banana"}, names(first.Items))
+	assert.Empty(t, first.Prev)
+	require.NotEmpty(t, first.Next)
+	assert.Nil(t, first.Total)
+
+	req.Cursor = first.Next
+	second, err := repo.ListCursor(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cherry", "apricot"}, names(second.Items))
+	assert.Empty(t, second.Next)
+	require.NotEmpty(t, second.Prev)
+
+	req.Cursor = second.Prev
+	back, err := repo.ListCursor(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apple", "banana"}, names(back.Items))
+	assert.Empty(t, back.Prev)
+	assert.NotEmpty(t, back.Next)
+}
+
+func TestListCursorDescendingWithTiesAndTotal(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	ctx := context.Background()
+	require.NoError(t, db.Model(&specItem{}).Where("name IN ?", []string{"banana", "cherry"}).Update("price", 40).Error)
+
+	req := CursorRequest{Limit: 1, SortBy: "Price", Desc: true, Filter: NewSpec(Gt("price", 10)), WithTotal: true}
+	var seen []string
+	for {
+		page, err := repo.ListCursor(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, page.Total)
+		assert.Equal(t, int64(3), *page.Total)
+		seen = append(seen, names(page.Items)...)
+		if page.Next == "" {
+			break
+		}
+		req.Cursor = page.Next
+	}
+	assert.ElementsMatch(t, []string{"banana", "cherry", "apricot"}, seen)
+	assert.Len(t, seen, 3)
+}
+
+func TestListCursorRejectsInvalidCursors(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	ctx := context.Background()
+
+	page, err := repo.ListCursor(ctx, CursorRequest{Limit: 1, SortBy: "price"})
+	require.NoError(t, err)
+
+	tampered := []byte(page.Next)
+	tampered[2] ^= 1
+	_, err = repo.ListCursor(ctx, CursorRequest{Limit: 1, SortBy: "price", Cursor: string(tampered)})
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+
+	_, err = repo.ListCursor(ctx, CursorRequest{Limit: 1, SortBy: "name", Cursor: page.Next})
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+
+	other := NewCrudRepository[specItem, uuid.UUID](db, WithCursorKey([]byte("other")))
+	_, err = other.ListCursor(ctx, CursorRequest{Limit: 1, SortBy: "price", Cursor: page.Next})
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+
+	_, err = repo.ListCursor(ctx, CursorRequest{SortBy: "missing"})
+	assert.True(t, errors.Is(err, ErrUnknownField))
+}
+
+func TestListCursorSharedKey(t *testing.T) {
+	_, db := newSpecRepo(t)
+	ctx := context.Background()
+	key := []byte("shared")
+	req := CursorRequest{Limit: 2, SortBy: "price"}
+
+	page, err := NewCrudRepository[specItem, uuid.UUID](db, WithCursorKey(key)).ListCursor(ctx, req)
+	require.NoError(t, err)
+
+	req.Cursor = page.Next
+	next, err := NewCrudRepository[specItem, uuid.UUID](db, WithCursorKey(key)).ListCursor(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cherry", "apricot"}, names(next.Items))
+
+	// A cursor of another entity sorted by the same field does not verify.
+	type pricedItem struct {
+		ID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+		Price int
+	}
+	require.NoError(t, db.AutoMigrate(&pricedItem{}))
+	_, err = NewCrudRepository[pricedItem, uuid.UUID](db, WithCursorKey(key)).ListCursor(ctx, req)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidKey is returned when an ID does not match the entity's primary key.
+var ErrInvalidKey = errors.New("invalid key")
+
+// keyCondition returns the condition selecting the row with the given ID.
+//
+// A scalar ID such as int64, string or uuid.UUID matches the single primary
+// key. For composite keys ID is a key struct whose fields are named after the
+// primary key fields, e.g.
+//
+//	type OrderLineKey struct {
+//		OrderID uuid.UUID
+//		Line    int
+//	}
+func keyCondition[ID comparable](sch *schema.Schema, id ID) (clause.Expression, error) {
+	rv := reflect.ValueOf(id)
+	if !isKeyStruct(rv.Type()) {
+		pk := sch.PrioritizedPrimaryField
+		if pk == nil {
+			return nil, fmt.Errorf("%w: %s has a composite primary key, use a key struct", ErrInvalidKey, sch.Name)
+		}
+		return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: id}, nil
+	}
+
+	rt := rv.Type()
+	exprs := make([]clause.Expression, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		field := sch.LookUpField(sf.Name)
+		if field == nil || !field.PrimaryKey {
+			return nil, fmt.Errorf("%w: %s is not a primary key field of %s", ErrInvalidKey, sf.Name, sch.Name)
+		}
+		exprs = append(exprs, clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Value:  rv.Field(i).Interface(),
+		})
+	}
+	if len(exprs) != len(sch.PrimaryFields) {
+		return nil, fmt.Errorf("%w: %s does not cover the primary key of %s", ErrInvalidKey, rt.Name(), sch.Name)
+	}
+	return clause.And(exprs...), nil
+}
+
+// keysCondition returns the condition selecting the rows with the given IDs.
+func keysCondition[ID comparable](sch *schema.Schema, ids []ID) (clause.Expression, error) {
+	var zero ID
+	if !isKeyStruct(reflect.TypeOf(zero)) {
+		if _, err := keyCondition(sch, zero); err != nil {
+			return nil, err
+		}
+		values := make([]any, len(ids))
+		for i, id := range ids {
+			values[i] = id
+		}
+		col := clause.Column{Table: clause.CurrentTable, Name: sch.PrioritizedPrimaryField.DBName}
+		return clause.IN{Column: col, Values: values}, nil
+	}
+	exprs := make([]clause.Expression, len(ids))
+	for i, id := range ids {
+		expr, err := keyCondition(sch, id)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = expr
+	}
+	return clause.Or(exprs...), nil
+}
+
+var (
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType   = reflect.TypeOf(time.Time{})
+)
+
+// isKeyStruct reports whether t is a composite key struct rather than a scalar
+// column value such as time.Time or a type implementing driver.Valuer.
+func isKeyStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !t.Implements(valuerType) &&
+		!reflect.PointerTo(t).Implements(valuerType)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type numberedItem struct {
+	ID   int64 `gorm:"primaryKey"`
+	Name string
+}
+
+type orderLine struct {
+	OrderID string `gorm:"primaryKey"`
+	Line    int    `gorm:"primaryKey;autoIncrement:false"`
+	Product string
+}
+
+type orderLineKey struct {
+	OrderID string
+	Line    int
+}
+
+func TestInt64Keys(t *testing.T) {
+	_, db := newSpecRepo(t)
+	require.NoError(t, db.AutoMigrate(&numberedItem{}))
+	repo := NewCrudRepository[numberedItem, int64](db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateMany(ctx, []numberedItem{{Name: "a"}, {Name: "b"}, {Name: "c"}}))
+
+	got, err := repo.FindByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "b", got.Name)
+
+	require.NoError(t, repo.Delete(ctx, 1))
+	deleted, err := repo.DeleteMany(ctx, []int64{2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func TestCompositeKeys(t *testing.T) {
+	_, db := newSpecRepo(t)
+	require.NoError(t, db.AutoMigrate(&orderLine{}))
+	repo := NewCrudRepository[orderLine, orderLineKey](db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateMany(ctx, []orderLine{
+		{OrderID: "o1", Line: 1, Product: "pen"},
+		{OrderID: "o1", Line: 2, Product: "ink"},
+		{OrderID: "o2", Line: 1, Product: "pad"},
+	}))
+
+	got, err := repo.FindByID(ctx, orderLineKey{OrderID: "o1", Line: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "ink", got.Product)
+
+	_, err = repo.FindByID(ctx, orderLineKey{OrderID: "o2", Line: 2})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, repo.Delete(ctx, orderLineKey{OrderID: "o1", Line: 1}))
+	deleted, err := repo.DeleteMany(ctx, []orderLineKey{{OrderID: "o1", Line: 2}, {OrderID: "o2", Line: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	// A scalar ID cannot address a composite key.
+	scalar := NewCrudRepository[orderLine, string](db)
+	_, err = scalar.FindByID(ctx, "o1")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+
+	// Key structs must name primary key fields only.
+	type badKey struct{ OrderID, Product string }
+	bad := NewCrudRepository[orderLine, badKey](db)
+	_, err = bad.FindByID(ctx, badKey{OrderID: "o1"})
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+}
@@ -2,83 +2,151 @@ package mocks
 
 import (
 	"context"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/ebrickdev/ebrick/repository"
 	"github.com/stretchr/testify/mock"
 )
 
 // MockCrudRepository is a mock implementation of the generic CrudRepository interface.
-type MockCrudRepository[T any] struct {
+type MockCrudRepository[T any, ID comparable] struct {
 	mock.Mock
 }
 
-func (m *MockCrudRepository[T]) Create(ctx context.Context, entity *T) (*T, error) {
+var _ repository.CrudRepository[struct{}, int64] = (*MockCrudRepository[struct{}, int64])(nil)
+
+func (m *MockCrudRepository[T, ID]) Create(ctx context.Context, entity *T) (*T, error) {
 	args := m.Called(ctx, entity)
 	return args.Get(0).(*T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) FindByID(ctx context.Context, id uuid.UUID) (*T, error) {
+func (m *MockCrudRepository[T, ID]) FindByID(ctx context.Context, id ID) (*T, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) Update(ctx context.Context, entity *T) (*T, error) {
+func (m *MockCrudRepository[T, ID]) Update(ctx context.Context, entity *T) (*T, error) {
 	args := m.Called(ctx, entity)
 	return args.Get(0).(*T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockCrudRepository[T, ID]) Delete(ctx context.Context, id ID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockCrudRepository[T]) ListAll(ctx context.Context) ([]T, error) {
+func (m *MockCrudRepository[T, ID]) ListAll(ctx context.Context) ([]T, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) First(ctx context.Context, entity *T) (*T, error) {
+func (m *MockCrudRepository[T, ID]) First(ctx context.Context, entity *T) (*T, error) {
 	args := m.Called(ctx, entity)
 	return args.Get(0).(*T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) FindWithEntity(ctx context.Context, entity *T) ([]T, error) {
+func (m *MockCrudRepository[T, ID]) FindWithEntity(ctx context.Context, entity *T) ([]T, error) {
 	args := m.Called(ctx, entity)
 	return args.Get(0).([]T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) FindWithConditions(ctx context.Context, conditions map[string]interface{}) ([]T, error) {
+func (m *MockCrudRepository[T, ID]) FindWithConditions(ctx context.Context, conditions map[string]interface{}) ([]T, error) {
 	args := m.Called(ctx, conditions)
 	return args.Get(0).([]T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) FindWithOrConditions(ctx context.Context, conditions map[string]interface{}) ([]T, error) {
+func (m *MockCrudRepository[T, ID]) FindWithOrConditions(ctx context.Context, conditions map[string]interface{}) ([]T, error) {
 	args := m.Called(ctx, conditions)
 	return args.Get(0).([]T), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) CountWithConditions(ctx context.Context, conditions map[string]interface{}) (int64, error) {
+func (m *MockCrudRepository[T, ID]) CountWithConditions(ctx context.Context, conditions map[string]interface{}) (int64, error) {
 	args := m.Called(ctx, conditions)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) CountWithEntity(ctx context.Context, entity *T) (int64, error) {
+func (m *MockCrudRepository[T, ID]) CountWithEntity(ctx context.Context, entity *T) (int64, error) {
 	args := m.Called(ctx, entity)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockCrudRepository[T]) Exists(ctx context.Context, conditions map[string]interface{}) (bool, error) {
+func (m *MockCrudRepository[T, ID]) Exists(ctx context.Context, conditions map[string]interface{}) (bool, error) {
 	args := m.Called(ctx, conditions)
 	return args.Get(0).(bool), args.Error(1)
 }
 
 // Add mock for new paging method
-func (m *MockCrudRepository[T]) ListPaged(ctx context.Context, offset int, limit int) ([]T, int64, error) {
+func (m *MockCrudRepository[T, ID]) ListPaged(ctx context.Context, offset int, limit int) ([]T, int64, error) {
 	args := m.Called(ctx, offset, limit)
 	return args.Get(0).([]T), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockCrudRepository[T]) Patch(ctx context.Context, entity *T) (*T, error) {
+func (m *MockCrudRepository[T, ID]) Patch(ctx context.Context, entity *T) (*T, error) {
 	args := m.Called(ctx, entity)
 	return args.Get(0).(*T), args.Error(1)
 }
+
+func (m *MockCrudRepository[T, ID]) FindBySpec(ctx context.Context, spec *repository.Spec) ([]T, error) {
+	args := m.Called(ctx, spec)
+	return args.Get(0).([]T), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) CountBySpec(ctx context.Context, spec *repository.Spec) (int64, error) {
+	args := m.Called(ctx, spec)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) ExistsBySpec(ctx context.Context, spec *repository.Spec) (bool, error) {
+	args := m.Called(ctx, spec)
+	return args.Get(0).(bool), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) ListCursor(ctx context.Context, req repository.CursorRequest) (*repository.CursorPage[T], error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*repository.CursorPage[T]), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) Restore(ctx context.Context, id ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCrudRepository[T, ID]) HardDelete(ctx context.Context, id ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCrudRepository[T, ID]) ListDeleted(ctx context.Context) ([]T, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]T), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(ctx, retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) CreateMany(ctx context.Context, ets []T, opts ...repository.BulkOption) error {
+	args := m.Called(ctx, ets)
+	return args.Error(0)
+}
+
+func (m *MockCrudRepository[T, ID]) UpsertMany(ctx context.Context, ets []T, conflictColumns []string, opts ...repository.BulkOption) error {
+	args := m.Called(ctx, ets, conflictColumns)
+	return args.Error(0)
+}
+
+func (m *MockCrudRepository[T, ID]) UpdateWhere(ctx context.Context, spec *repository.Spec, fields map[string]any) (int64, error) {
+	args := m.Called(ctx, spec, fields)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) DeleteWhere(ctx context.Context, spec *repository.Spec) (int64, error) {
+	args := m.Called(ctx, spec)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCrudRepository[T, ID]) DeleteMany(ctx context.Context, ids []ID, opts ...repository.BulkOption) (int64, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
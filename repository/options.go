@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"crypto/rand"
+
+	"gorm.io/gorm"
+)
+
+// Options configures a CrudRepository.
+type Options struct {
+	// CursorKey signs pagination cursors. When empty a random key is generated
+	// per repository, so cursors are rejected by other repositories, replicas
+	// and after a restart.
+	CursorKey []byte
+	// ReadDB serves read queries, typically a replica. Writes, transactions and
+	// reads with a context from ForcePrimary use the primary database.
+	ReadDB *gorm.DB
+}
+
+type Option func(*Options)
+
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+	if len(options.CursorKey) == 0 {
+		options.CursorKey = make([]byte, 32)
+		_, _ = rand.Read(options.CursorKey)
+	}
+	return options
+}
+
+// WithCursorKey sets the HMAC key used to sign pagination cursors.
+func WithCursorKey(key []byte) Option {
+	return func(o *Options) {
+		o.CursorKey = key
+	}
+}
+
+// WithReadDB routes read queries to db, typically a replica.
+func WithReadDB(db *gorm.DB) Option {
+	return func(o *Options) {
+		o.ReadDB = db
+	}
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "context"
+
+// forcePrimaryKey is the context key set by ForcePrimary.
+type forcePrimaryKey struct{}
+
+// ForcePrimary returns a copy of ctx in which repositories read from the
+// primary database instead of their read database. Use it right after a write
+// to read it back despite replication lag.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+func forcesPrimary(ctx context.Context) bool {
+	force, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return force
+}
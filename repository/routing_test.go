@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newReplica returns a replica of the specItem table holding only a "lagging" row.
+func newReplica(t *testing.T) *gorm.DB {
+	t.Helper()
+	replica, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := replica.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	require.NoError(t, replica.AutoMigrate(&specItem{}))
+	require.NoError(t, replica.Create(&specItem{ID: uuid.New(), Name: "lagging"}).Error)
+	return replica
+}
+
+func TestReadDBRouting(t *testing.T) {
+	_, primary := newSpecRepo(t)
+	replica := newReplica(t)
+
+	repo := NewCrudRepository[specItem, uuid.UUID](primary, WithReadDB(replica))
+	ctx := context.Background()
+
+	all, err := repo.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lagging"}, names(all))
+
+	created, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "fresh"})
+	require.NoError(t, err)
+	_, err = repo.FindByID(ctx, created.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	found, err := repo.FindByID(ForcePrimary(ctx), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", found.Name)
+
+	err = NewTxManager(primary).WithinTx(ctx, func(ctx context.Context) error {
+		count, err := repo.CountBySpec(ctx, NewSpec())
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+		return nil
+	})
+	require.NoError(t, err)
+}
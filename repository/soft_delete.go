@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotSoftDeletable is returned by soft-delete operations on entities
+// without a gorm.DeletedAt field.
+var ErrNotSoftDeletable = errors.New("entity is not soft deletable")
+
+// includeDeletedKey is the context key of the include-deleted flag.
+type includeDeletedKey struct{}
+
+// IncludeDeleted returns a copy of ctx in which repository queries also see
+// soft-deleted rows.
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// includesDeleted reports whether ctx was returned by IncludeDeleted.
+func includesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
+
+func (r *crudRepository[T, ID]) Restore(ctx context.Context, id ID) error {
+	deletedAt, err := r.softDelete()
+	if err != nil {
+		return err
+	}
+	cond, err := r.keyCondition(id)
+	if err != nil {
+		return err
+	}
+	res := r.conn(ctx).Unscoped().Model(new(T)).
+		Where(cond).
+		Where(clause.Neq{Column: deletedAt, Value: nil}).
+		Update(deletedAt.Name, nil)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+func (r *crudRepository[T, ID]) HardDelete(ctx context.Context, id ID) error {
+	cond, err := r.keyCondition(id)
+	if err != nil {
+		return err
+	}
+	return r.conn(ctx).Unscoped().Where(cond).Delete(new(T)).Error
+}
+
+func (r *crudRepository[T, ID]) ListDeleted(ctx context.Context) ([]T, error) {
+	deletedAt, err := r.softDelete()
+	if err != nil {
+		return nil, err
+	}
+	var entities []T
+	err = r.reader(ctx).Unscoped().Where(clause.Neq{Column: deletedAt, Value: nil}).Find(&entities).Error
+	return entities, err
+}
+
+func (r *crudRepository[T, ID]) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	deletedAt, err := r.softDelete()
+	if err != nil {
+		return 0, err
+	}
+	res := r.conn(ctx).Unscoped().
+		Where(clause.Lt{Column: deletedAt, Value: time.Now().Add(-retention)}).
+		Delete(new(T))
+	return res.RowsAffected, res.Error
+}
+
+// softDelete returns the gorm.DeletedAt column of T.
+func (r *crudRepository[T, ID]) softDelete() (clause.Column, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return clause.Column{}, err
+	}
+	for _, f := range sch.Fields {
+		if f.FieldType == reflect.TypeOf(gorm.DeletedAt{}) && f.DBName != "" {
+			return clause.Column{Table: clause.CurrentTable, Name: f.DBName}, nil
+		}
+	}
+	return clause.Column{}, fmt.Errorf("%w: %s", ErrNotSoftDeletable, sch.Name)
+}
+
+// Purger permanently removes rows soft-deleted longer ago than retention.
+type Purger interface {
+	Purge(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// SchedulePurge purges the repositories every interval until ctx is done or
+// the returned function is called. Failures are logged and retried on the next run.
+func SchedulePurge(ctx context.Context, interval, retention time.Duration, purgers ...Purger) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, p := range purgers {
+					purged, err := p.Purge(ctx, retention)
+					if err != nil {
+						logger.FromContext(ctx).Error("Failed to purge soft-deleted rows", logger.Error(err))
+						continue
+					}
+					if purged > 0 {
+						logger.FromContext(ctx).Info("Purged soft-deleted rows", logger.Int("rows", int(purged)))
+					}
+				}
+			}
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ebrickdev/ebrick/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type deletableItem struct {
+	ID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name string
+	entity.Auditing
+}
+
+func newDeletableRepo(t *testing.T) (CrudRepository[deletableItem, uuid.UUID], *gorm.DB) {
+	t.Helper()
+	_, db := newSpecRepo(t)
+	require.NoError(t, db.AutoMigrate(&deletableItem{}))
+	return NewCrudRepository[deletableItem, uuid.UUID](db), db
+}
+
+func TestRestoreAndListDeleted(t *testing.T) {
+	repo, _ := newDeletableRepo(t)
+	ctx := context.Background()
+
+	item, err := repo.Create(ctx, &deletableItem{ID: uuid.New(), Name: "a"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &deletableItem{ID: uuid.New(), Name: "b"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, item.ID))
+
+	all, err := repo.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	all, err = repo.ListAll(IncludeDeleted(ctx))
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	deleted, err := repo.ListDeleted(ctx)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, item.ID, deleted[0].ID)
+
+	require.NoError(t, repo.Restore(ctx, item.ID))
+	restored, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+
+	// Restoring a live row finds nothing to restore.
+	assert.ErrorIs(t, repo.Restore(ctx, item.ID), gorm.ErrRecordNotFound)
+}
+
+func TestHardDeleteAndPurge(t *testing.T) {
+	repo, db := newDeletableRepo(t)
+	ctx := context.Background()
+
+	old, err := repo.Create(ctx, &deletableItem{ID: uuid.New(), Name: "old"})
+	require.NoError(t, err)
+	recent, err := repo.Create(ctx, &deletableItem{ID: uuid.New(), Name: "recent"})
+	require.NoError(t, err)
+	gone, err := repo.Create(ctx, &deletableItem{ID: uuid.New(), Name: "gone"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, old.ID))
+	require.NoError(t, repo.Delete(ctx, recent.ID))
+	require.NoError(t, db.Unscoped().Model(&deletableItem{}).Where("id = ?", old.ID).
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+
+	purged, err := repo.Purge(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	require.NoError(t, repo.HardDelete(ctx, gone.ID))
+
+	all, err := repo.ListAll(IncludeDeleted(ctx))
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, recent.ID, all[0].ID)
+}
+
+func TestSoftDeleteRequiresDeletedAt(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+
+	_, err := repo.ListDeleted(context.Background())
+	assert.True(t, errors.Is(err, ErrNotSoftDeletable))
+}
+
+type purgerFunc func(ctx context.Context, retention time.Duration) (int64, error)
+
+func (f purgerFunc) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	return f(ctx, retention)
+}
+
+func TestSchedulePurge(t *testing.T) {
+	calls := make(chan time.Duration, 10)
+	stop := SchedulePurge(context.Background(), 5*time.Millisecond, time.Hour, purgerFunc(func(_ context.Context, retention time.Duration) (int64, error) {
+		calls <- retention
+		return 0, nil
+	}))
+	defer stop()
+
+	select {
+	case retention := <-calls:
+		assert.Equal(t, time.Hour, retention)
+	case <-time.After(time.Second):
+		t.Fatal("purge did not run")
+	}
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrUnknownField is returned when a spec or condition map references a field
+// that is not part of the entity's GORM schema.
+var ErrUnknownField = errors.New("unknown field")
+
+// Operator is a comparison operator of a spec predicate.
+type Operator string
+
+const (
+	OpEq        Operator = "eq"
+	OpNe        Operator = "ne"
+	OpGt        Operator = "gt"
+	OpGte       Operator = "gte"
+	OpLt        Operator = "lt"
+	OpLte       Operator = "lte"
+	OpIn        Operator = "in"
+	OpNotIn     Operator = "not_in"
+	OpLike      Operator = "like"
+	OpBetween   Operator = "between"
+	OpIsNull    Operator = "is_null"
+	OpIsNotNull Operator = "is_not_null"
+)
+
+// Condition is a predicate or a group of predicates in a Spec.
+type Condition interface {
+	// build resolves field names against sch and returns the SQL expression.
+	build(sch *schema.Schema) (clause.Expression, error)
+	// String returns a stable textual form, usable as a cache key.
+	String() string
+}
+
+// predicate compares a single field.
+type predicate struct {
+	field  string
+	op     Operator
+	values []any
+}
+
+// Eq matches rows where field equals value. A nil value matches NULL.
+func Eq(field string, value any) Condition { return predicate{field, OpEq, []any{value}} }
+
+// Ne matches rows where field differs from value.
+func Ne(field string, value any) Condition { return predicate{field, OpNe, []any{value}} }
+
+// Gt matches rows where field is greater than value.
+func Gt(field string, value any) Condition { return predicate{field, OpGt, []any{value}} }
+
+// Gte matches rows where field is greater than or equal to value.
+func Gte(field string, value any) Condition { return predicate{field, OpGte, []any{value}} }
+
+// Lt matches rows where field is less than value.
+func Lt(field string, value any) Condition { return predicate{field, OpLt, []any{value}} }
+
+// Lte matches rows where field is less than or equal to value.
+func Lte(field string, value any) Condition { return predicate{field, OpLte, []any{value}} }
+
+// In matches rows where field equals one of values.
+func In(field string, values ...any) Condition { return predicate{field, OpIn, values} }
+
+// NotIn matches rows where field equals none of values.
+func NotIn(field string, values ...any) Condition { return predicate{field, OpNotIn, values} }
+
+// Like matches rows where field matches the SQL LIKE pattern.
+func Like(field string, pattern string) Condition { return predicate{field, OpLike, []any{pattern}} }
+
+// Between matches rows where field lies within [from, to].
+func Between(field string, from, to any) Condition {
+	return predicate{field, OpBetween, []any{from, to}}
+}
+
+// IsNull matches rows where field is NULL.
+func IsNull(field string) Condition { return predicate{field, OpIsNull, nil} }
+
+// IsNotNull matches rows where field is not NULL.
+func IsNotNull(field string) Condition { return predicate{field, OpIsNotNull, nil} }
+
+func (p predicate) build(sch *schema.Schema) (clause.Expression, error) {
+	col, err := column(sch, p.field)
+	if err != nil {
+		return nil, err
+	}
+	switch p.op {
+	case OpEq:
+		return clause.Eq{Column: col, Value: p.values[0]}, nil
+	case OpNe:
+		return clause.Neq{Column: col, Value: p.values[0]}, nil
+	case OpGt:
+		return clause.Gt{Column: col, Value: p.values[0]}, nil
+	case OpGte:
+		return clause.Gte{Column: col, Value: p.values[0]}, nil
+	case OpLt:
+		return clause.Lt{Column: col, Value: p.values[0]}, nil
+	case OpLte:
+		return clause.Lte{Column: col, Value: p.values[0]}, nil
+	case OpIn:
+		return clause.IN{Column: col, Values: p.values}, nil
+	case OpNotIn:
+		return clause.Not(clause.IN{Column: col, Values: p.values}), nil
+	case OpLike:
+		return clause.Like{Column: col, Value: p.values[0]}, nil
+	case OpBetween:
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{col, p.values[0], p.values[1]}}, nil
+	case OpIsNull:
+		return clause.Expr{SQL: "? IS NULL", Vars: []any{col}}, nil
+	case OpIsNotNull:
+		return clause.Expr{SQL: "? IS NOT NULL", Vars: []any{col}}, nil
+	}
+	return nil, fmt.Errorf("unsupported operator: %s", p.op)
+}
+
+// String renders the predicate unambiguously: each value is written with its
+// type and JSON encoding, so "1" and 1, or ["a b"] and ["a", "b"], differ.
+func (p predicate) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q %s [", p.field, p.op)
+	for i, v := range p.values {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(encodeValue(v))
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// encodeValue returns the type and JSON encoding of v, or its quoted default
+// format when it cannot be encoded.
+func encodeValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%T:%q", v, fmt.Sprint(v))
+	}
+	return fmt.Sprintf("%T:%s", v, data)
+}
+
+// group combines conditions with AND or OR.
+type group struct {
+	or    bool
+	conds []Condition
+}
+
+// And matches rows that satisfy all conditions.
+func And(conds ...Condition) Condition { return group{or: false, conds: conds} }
+
+// Or matches rows that satisfy at least one condition.
+func Or(conds ...Condition) Condition { return group{or: true, conds: conds} }
+
+func (g group) build(sch *schema.Schema) (clause.Expression, error) {
+	exprs := make([]clause.Expression, 0, len(g.conds))
+	for _, c := range g.conds {
+		expr, err := c.build(sch)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	if g.or {
+		return clause.Or(exprs...), nil
+	}
+	return clause.And(exprs...), nil
+}
+
+func (g group) String() string {
+	parts := make([]string, len(g.conds))
+	for i, c := range g.conds {
+		parts[i] = c.String()
+	}
+	sep := " AND "
+	if g.or {
+		sep = " OR "
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// sortOrder is a single ORDER BY item.
+type sortOrder struct {
+	field string
+	desc  bool
+}
+
+// Spec describes a query: conditions combined with AND, sorting, field
+// projection and an optional limit and offset. Field names may be given as Go
+// field names or column names and are validated against the entity's schema.
+type Spec struct {
+	conds  []Condition
+	orders []sortOrder
+	fields []string
+	limit  int
+	offset int
+}
+
+// NewSpec creates a Spec matching rows that satisfy all conditions.
+func NewSpec(conds ...Condition) *Spec {
+	return &Spec{conds: conds}
+}
+
+// Where adds conditions combined with AND.
+func (s *Spec) Where(conds ...Condition) *Spec {
+	s.conds = append(s.conds, conds...)
+	return s
+}
+
+// OrderBy sorts by field ascending.
+func (s *Spec) OrderBy(field string) *Spec {
+	s.orders = append(s.orders, sortOrder{field: field})
+	return s
+}
+
+// OrderByDesc sorts by field descending.
+func (s *Spec) OrderByDesc(field string) *Spec {
+	s.orders = append(s.orders, sortOrder{field: field, desc: true})
+	return s
+}
+
+// Select restricts the loaded fields; other fields keep their zero value.
+func (s *Spec) Select(fields ...string) *Spec {
+	s.fields = append(s.fields, fields...)
+	return s
+}
+
+// Limit caps the number of returned rows; 0 means no limit.
+func (s *Spec) Limit(limit int) *Spec {
+	s.limit = limit
+	return s
+}
+
+// Offset skips the first rows.
+func (s *Spec) Offset(offset int) *Spec {
+	s.offset = offset
+	return s
+}
+
+// Filter returns a spec with only s's conditions, matching the rows that
+// UpdateWhere and DeleteWhere change.
+func (s *Spec) Filter() *Spec {
+	if s == nil {
+		return nil
+	}
+	return NewSpec(s.conds...)
+}
+
+// String returns a stable textual form of the spec, usable as a cache key.
+func (s *Spec) String() string {
+	if s == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(And(s.conds...).String())
+	for _, o := range s.orders {
+		fmt.Fprintf(&b, " order:%q:%t", o.field, o.desc)
+	}
+	if len(s.fields) > 0 {
+		fmt.Fprintf(&b, " select:%q", s.fields)
+	}
+	fmt.Fprintf(&b, " limit:%d offset:%d", s.limit, s.offset)
+	return b.String()
+}
+
+// applyWhere adds the spec's conditions to db.
+func (s *Spec) applyWhere(db *gorm.DB, sch *schema.Schema) (*gorm.DB, error) {
+	if s == nil || len(s.conds) == 0 {
+		return db, nil
+	}
+	expr, err := And(s.conds...).build(sch)
+	if err != nil {
+		return nil, err
+	}
+	return db.Where(expr), nil
+}
+
+// apply adds conditions, sorting, projection, limit and offset to db.
+func (s *Spec) apply(db *gorm.DB, sch *schema.Schema) (*gorm.DB, error) {
+	db, err := s.applyWhere(db, sch)
+	if err != nil || s == nil {
+		return db, err
+	}
+	for _, o := range s.orders {
+		col, err := column(sch, o.field)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Order(clause.OrderByColumn{Column: col, Desc: o.desc})
+	}
+	if len(s.fields) > 0 {
+		names := make([]string, len(s.fields))
+		for i, f := range s.fields {
+			col, err := column(sch, f)
+			if err != nil {
+				return nil, err
+			}
+			names[i] = col.Name
+		}
+		db = db.Select(names)
+	}
+	if s.limit > 0 {
+		db = db.Limit(s.limit)
+	}
+	if s.offset > 0 {
+		db = db.Offset(s.offset)
+	}
+	return db, nil
+}
+
+// column resolves a Go field name or column name to a column of sch.
+func column(sch *schema.Schema, name string) (clause.Column, error) {
+	field := sch.LookUpField(name)
+	if field == nil || field.DBName == "" {
+		return clause.Column{}, fmt.Errorf("%w: %s.%s", ErrUnknownField, sch.Name, name)
+	}
+	return clause.Column{Table: clause.CurrentTable, Name: field.DBName}, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type specItem struct {
+	ID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name  string
+	Price int
+	Note  *string
+}
+
+func newSpecRepo(t *testing.T) (CrudRepository[specItem, uuid.UUID], *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&specItem{}))
+
+	note := "fragile"
+	items := []specItem{
+		{ID: uuid.New(), Name: "apple", Price: 10},
+		{ID: uuid.New(), Name: "banana", Price: 20, Note: &note},
+		{ID: uuid.New(), Name: "cherry", Price: 30},
+		{ID: uuid.New(), Name: "apricot", Price: 40},
+	}
+	require.NoError(t, db.Create(&items).Error)
+	return NewCrudRepository[specItem, uuid.UUID](db), db
+}
+
+func names(items []specItem) []string {
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = it.Name
+	}
+	return out
+}
+
+func TestFindBySpec(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		spec *Spec
+		want []string
+	}{
+		{"eq", NewSpec(Eq("Name", "apple")), []string{"apple"}},
+		{"ne", NewSpec(Ne("name", "apple")).OrderBy("name"), []string{"apricot", "banana", "cherry"}},
+		{"gt lt", NewSpec(Gt("price", 10), Lt("price", 40)).OrderBy("price"), []string{"banana", "cherry"}},
+		{"gte lte", NewSpec(Gte("price", 30), Lte("price", 40)).OrderBy("price"), []string{"cherry", "apricot"}},
+		{"in", NewSpec(In("name", "apple", "cherry")).OrderBy("name"), []string{"apple", "cherry"}},
+		{"not in", NewSpec(NotIn("name", "apple", "cherry")).OrderBy("name"), []string{"apricot", "banana"}},
+		{"like", NewSpec(Like("name", "ap%")).OrderByDesc("name"), []string{"apricot", "apple"}},
+		{"between", NewSpec(Between("price", 20, 30)).OrderBy("price"), []string{"banana", "cherry"}},
+		{"is null", NewSpec(IsNull("note"), Gt("price", 20)).OrderBy("price"), []string{"cherry", "apricot"}},
+		{"is not null", NewSpec(IsNotNull("Note")), []string{"banana"}},
+		{"or", NewSpec(Or(Eq("name", "apple"), Gte("price", 40))).OrderBy("price"), []string{"apple", "apricot"}},
+		{"nested", NewSpec(Or(And(Like("name", "a%"), Gt("price", 10)), Eq("name", "banana"))).OrderBy("price"), []string{"banana", "apricot"}},
+		{"limit offset", NewSpec().OrderBy("price").Limit(2).Offset(1), []string{"banana", "cherry"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindBySpec(ctx, tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, names(got))
+		})
+	}
+}
+
+func TestFindBySpecSelect(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+
+	got, err := repo.FindBySpec(context.Background(), NewSpec(Eq("name", "banana")).Select("name"))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "banana", got[0].Name)
+	assert.Zero(t, got[0].Price)
+	assert.Nil(t, got[0].Note)
+}
+
+func TestCountAndExistsBySpec(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+	ctx := context.Background()
+
+	count, err := repo.CountBySpec(ctx, NewSpec(Gte("price", 20)).OrderBy("name").Limit(1))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	exists, err := repo.ExistsBySpec(ctx, NewSpec(Eq("name", "cherry")))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.ExistsBySpec(ctx, NewSpec(Eq("name", "durian")))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestSpecUnknownField(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+	ctx := context.Background()
+
+	_, err := repo.FindBySpec(ctx, NewSpec(Eq("name; DROP TABLE spec_items", 1)))
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	_, err = repo.FindBySpec(ctx, NewSpec().OrderBy("missing"))
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	_, err = repo.FindBySpec(ctx, NewSpec().Select("missing"))
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	_, err = repo.CountBySpec(ctx, NewSpec(Or(Eq("missing", 1))))
+	assert.True(t, errors.Is(err, ErrUnknownField))
+}
+
+func TestFindWithOrConditions(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	ctx := context.Background()
+
+	got, err := repo.FindWithOrConditions(ctx, map[string]any{"name": "apple", "Price": 30})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"apple", "cherry"}, names(got))
+
+	_, err = repo.FindWithOrConditions(ctx, map[string]any{"1=1 OR name": "x"})
+	assert.True(t, errors.Is(err, ErrUnknownField))
+
+	var count int64
+	require.NoError(t, db.Model(&specItem{}).Count(&count).Error)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestFindWithConditions(t *testing.T) {
+	repo, _ := newSpecRepo(t)
+	ctx := context.Background()
+
+	got, err := repo.FindWithConditions(ctx, map[string]any{"Name": "banana", "price": 20})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"banana"}, names(got))
+	count, err := repo.CountWithConditions(ctx, map[string]any{"note": nil})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	exists, err := repo.Exists(ctx, map[string]any{"name": "durian"})
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	conditions := map[string]any{"1=1 OR name": "x"}
+	_, err = repo.FindWithConditions(ctx, conditions)
+	assert.ErrorIs(t, err, ErrUnknownField)
+	_, err = repo.CountWithConditions(ctx, conditions)
+	assert.ErrorIs(t, err, ErrUnknownField)
+	_, err = repo.Exists(ctx, conditions)
+	assert.ErrorIs(t, err, ErrUnknownField)
+}
+
+func TestSpecString(t *testing.T) {
+	a := NewSpec(Eq("name", "x"), Or(Gt("price", 1), IsNull("note"))).OrderByDesc("price").Select("name").Limit(5)
+	b := NewSpec(Eq("name", "x"), Or(Gt("price", 1), IsNull("note"))).OrderByDesc("price").Select("name").Limit(5)
+	assert.Equal(t, a.String(), b.String())
+	assert.NotEqual(t, a.String(), NewSpec(Eq("name", "y")).String())
+	assert.Equal(t, "", (*Spec)(nil).String())
+
+	// Values are rendered with their type and without ambiguity.
+	assert.NotEqual(t, NewSpec(In("name", "a b")).String(), NewSpec(In("name", "a", "b")).String())
+	assert.NotEqual(t, NewSpec(Eq("x", "1")).String(), NewSpec(Eq("x", 1)).String())
+	assert.NotEqual(t, NewSpec(Eq("x", "a,b")).String(), NewSpec(In("x", "a", "b")).String())
+	assert.NotEqual(t, NewSpec().Select("a,b").String(), NewSpec().Select("a", "b").String())
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the active transaction.
+type txKey struct{}
+
+// connKey is the context key of the database replacing the repository's own.
+type connKey struct{}
+
+// commitHooksKey is the context key of the functions run after the active
+// transaction commits.
+type commitHooksKey struct{}
+
+// commitHooks collects the functions registered with AfterCommit in one
+// transaction or savepoint.
+type commitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+func (h *commitHooks) add(fns ...func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fns...)
+}
+
+func (h *commitHooks) run() {
+	for _, fn := range h.fns {
+		fn()
+	}
+}
+
+// AfterCommit runs fn once the TxManager transaction carried by ctx commits,
+// and drops it when the transaction or the savepoint fn was registered in rolls
+// back. Without such a transaction fn runs immediately.
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks); ok {
+		hooks.add(fn)
+		return
+	}
+	fn()
+}
+
+// ContextWithTx returns a copy of ctx carrying tx. Repositories called with the
+// returned context run their statements inside tx.
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+// ContextWithConn returns a copy of ctx whose repositories and transactions
+// run on conn instead of their own database, e.g. the database of a tenant or
+// a connection set to its schema.
+func ContextWithConn(ctx context.Context, conn *gorm.DB) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// ConnFromContext returns the database carried by ctx, if any.
+func ConnFromContext(ctx context.Context) (*gorm.DB, bool) {
+	conn, ok := ctx.Value(connKey{}).(*gorm.DB)
+	return conn, ok && conn != nil
+}
+
+// DBFromContext returns the transaction carried by ctx, or else the database
+// from ContextWithConn, or db, bound to ctx and unscoped if ctx came from
+// IncludeDeleted. Custom repositories use it to join a TxManager transaction.
+func DBFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		db = tx
+	} else if conn, ok := ConnFromContext(ctx); ok {
+		db = conn
+	}
+	db = db.WithContext(ctx)
+	if includesDeleted(ctx) {
+		db = db.Unscoped()
+	}
+	return db
+}
+
+// TxManager runs functions in a transaction shared by every repository that
+// receives the function's context.
+type TxManager interface {
+	// WithinTx runs fn in a transaction, committing if it returns nil and rolling
+	// back if it returns an error or panics. Nested calls run in a savepoint of
+	// the outer transaction and inherit its isolation level and read-only mode;
+	// their options are ignored.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error
+}
+
+// TxOptions configures a transaction.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+}
+
+type TxOption func(*TxOptions)
+
+// WithIsolation sets the isolation level, e.g. sql.LevelSerializable.
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// WithReadOnly starts a read-only transaction.
+func WithReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// NewTxManager creates a TxManager that starts transactions on db.
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+type txManager struct {
+	db *gorm.DB
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	hooks := &commitHooks{}
+	// GORM turns a transaction started on an open transaction into a savepoint.
+	if tx, ok := TxFromContext(ctx); ok {
+		err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ContextWithTx(ctx, tx), commitHooksKey{}, hooks))
+		})
+		if err == nil {
+			// The savepoint's hooks wait for the outer transaction.
+			AfterCommit(ctx, hooks.run)
+		}
+		return err
+	}
+
+	var options TxOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	db := m.db
+	if conn, ok := ConnFromContext(ctx); ok {
+		db = conn
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ContextWithTx(ctx, tx), commitHooksKey{}, hooks))
+	}, &sql.TxOptions{Isolation: options.Isolation, ReadOnly: options.ReadOnly})
+	if err == nil {
+		hooks.run()
+	}
+	return err
+}
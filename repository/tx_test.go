@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countItems(t *testing.T, repo CrudRepository[specItem, uuid.UUID], name string) int64 {
+	t.Helper()
+	n, err := repo.CountBySpec(context.Background(), NewSpec(Eq("name", name)))
+	require.NoError(t, err)
+	return n
+}
+
+func TestWithinTxCommitsAndRollsBack(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	tm := NewTxManager(db)
+	ctx := context.Background()
+
+	err := tm.WithinTx(ctx, func(ctx context.Context) error {
+		_, ok := TxFromContext(ctx)
+		assert.True(t, ok)
+		_, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "kiwi"})
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), countItems(t, repo, "kiwi"))
+
+	boom := errors.New("boom")
+	err = tm.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "lime"}); err != nil {
+			return err
+		}
+		// Reads in the transaction see its own writes.
+		exists, err := repo.ExistsBySpec(ctx, NewSpec(Eq("name", "lime")))
+		require.NoError(t, err)
+		assert.True(t, exists)
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Zero(t, countItems(t, repo, "lime"))
+}
+
+func TestWithinTxNestedSavepoint(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	tm := NewTxManager(db)
+	ctx := context.Background()
+
+	err := tm.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "outer"}); err != nil {
+			return err
+		}
+		inner := tm.WithinTx(ctx, func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "inner"}); err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		})
+		assert.Error(t, inner)
+		// Paged reads run their queries one at a time on the transaction.
+		_, total, err := repo.ListPaged(ctx, 0, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), countItems(t, repo, "outer"))
+	assert.Zero(t, countItems(t, repo, "inner"))
+}
+
+func TestWithinTxRollsBackOnPanic(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	tm := NewTxManager(db)
+
+	assert.Panics(t, func() {
+		_ = tm.WithinTx(context.Background(), func(ctx context.Context) error {
+			_, _ = repo.Create(ctx, &specItem{ID: uuid.New(), Name: "panic"})
+			panic("boom")
+		}, WithIsolation(sql.LevelSerializable), WithReadOnly())
+	})
+	assert.Zero(t, countItems(t, repo, "panic"))
+}
+
+func TestContextWithConn(t *testing.T) {
+	repo, db := newSpecRepo(t)
+	_, other := newSpecRepo(t)
+	require.NoError(t, other.Where("1 = 1").Delete(&specItem{}).Error)
+	ctx := ContextWithConn(context.Background(), other)
+
+	_, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "kiwi"})
+	require.NoError(t, err)
+	all, err := repo.ListAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kiwi"}, names(all))
+	assert.Zero(t, countItems(t, repo, "kiwi"))
+
+	err = NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+		_, err := repo.Create(ctx, &specItem{ID: uuid.New(), Name: "lime"})
+		return err
+	})
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, other.Model(&specItem{}).Where("name = ?", "lime").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	assert.Zero(t, countItems(t, repo, "lime"))
+}
+
+func TestAfterCommit(t *testing.T) {
+	_, db := newSpecRepo(t)
+	tm := NewTxManager(db)
+	var ran []string
+	hook := func(name string) func() { return func() { ran = append(ran, name) } }
+
+	AfterCommit(context.Background(), hook("no tx"))
+	err := tm.WithinTx(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, hook("outer"))
+		_ = tm.WithinTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, hook("rolled back"))
+			return errors.New("boom")
+		})
+		_ = tm.WithinTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, hook("savepoint"))
+			return nil
+		})
+		assert.Equal(t, []string{"no tx"}, ran)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"no tx", "outer", "savepoint"}, ran)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type versionedItem struct {
+	ID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name string
+	Qty  int
+	entity.Versioned
+}
+
+func newVersionedRepo(t *testing.T) CrudRepository[versionedItem, uuid.UUID] {
+	t.Helper()
+	_, db := newSpecRepo(t)
+	require.NoError(t, db.AutoMigrate(&versionedItem{}))
+	return NewCrudRepository[versionedItem, uuid.UUID](db)
+}
+
+func TestUpdateOptimisticLocking(t *testing.T) {
+	repo := newVersionedRepo(t)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &versionedItem{ID: uuid.New(), Name: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Version)
+
+	first, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	stale, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+
+	first.Name = "b"
+	_, err = repo.Update(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), first.Version)
+
+	stale.Name = "c"
+	_, err = repo.Update(ctx, stale)
+	assert.True(t, errors.Is(err, ErrConcurrentModification))
+	assert.Equal(t, int64(1), stale.Version)
+
+	got, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "b", got.Name)
+	assert.Equal(t, int64(2), got.Version)
+}
+
+func TestPatchOptimisticLocking(t *testing.T) {
+	repo := newVersionedRepo(t)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &versionedItem{ID: uuid.New(), Name: "a", Qty: 1})
+	require.NoError(t, err)
+
+	_, err = repo.Patch(ctx, &versionedItem{ID: created.ID, Qty: 5, Versioned: entity.Versioned{Version: 1}})
+	require.NoError(t, err)
+
+	_, err = repo.Patch(ctx, &versionedItem{ID: created.ID, Qty: 7, Versioned: entity.Versioned{Version: 1}})
+	assert.True(t, errors.Is(err, ErrConcurrentModification))
+
+	got, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a", got.Name)
+	assert.Equal(t, 5, got.Qty)
+	assert.Equal(t, int64(2), got.Version)
+}
+
+func TestUpdateVersionedRequiresPrimaryKey(t *testing.T) {
+	repo := newVersionedRepo(t)
+
+	_, err := repo.Update(context.Background(), &versionedItem{Name: "x", Versioned: entity.Versioned{Version: 1}})
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+}
+
+func TestBulkWritesIncrementVersion(t *testing.T) {
+	repo := newVersionedRepo(t)
+	ctx := context.Background()
+
+	a, b := versionedItem{ID: uuid.New(), Name: "a"}, versionedItem{ID: uuid.New(), Name: "b"}
+	require.NoError(t, repo.CreateMany(ctx, []versionedItem{a, b}))
+
+	n, err := repo.UpdateWhere(ctx, NewSpec(Eq("name", "a")), map[string]any{"qty": 5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	got, err := repo.FindByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Version)
+
+	// A stale copy loaded before the bulk update is rejected.
+	stale := a
+	stale.Version = 1
+	_, err = repo.Update(ctx, &stale)
+	assert.True(t, errors.Is(err, ErrConcurrentModification))
+
+	require.NoError(t, repo.UpsertMany(ctx, []versionedItem{{ID: a.ID, Name: "a2"}, {ID: uuid.New(), Name: "c"}}, nil))
+	got, err = repo.FindByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a2", got.Name)
+	assert.Equal(t, int64(3), got.Version)
+	created, err := repo.FindBySpec(ctx, NewSpec(Eq("name", "c")))
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, int64(1), created[0].Version)
+	got, err = repo.FindByID(ctx, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.Version)
+}
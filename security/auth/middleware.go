@@ -59,6 +59,12 @@ func (am *AuthMiddleware) TokenAuth() http.HandlerFunc {
 
 		c.Set("claims", principal)
 		c.Set("user_id", principal.GetID())
+
+		// Make the principal available to code that only receives the request
+		// context, and attach the user to the request-scoped logger.
+		ctx := ContextWithPrincipal(c.Request.Context(), principal)
+		ctx = logger.IntoContext(ctx, logger.FromContext(ctx).With(logger.UserField(principal.GetID())))
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
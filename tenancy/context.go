@@ -0,0 +1,79 @@
+// Package tenancy resolves the tenant of a request, carries it in the context
+// and isolates the data of entities embedding entity.Tenanted.
+//
+// A typical setup registers the middleware after authentication, so the tenant
+// is checked against the principal's claim, and the GORM plugin:
+//
+//	engine.Use(authMiddleware.TokenAuth(), tenancy.Middleware(tenancy.WithResolvers(
+//		tenancy.ClaimResolver("tenant_id"),
+//		tenancy.HeaderResolver(http.TenantIDHeader),
+//	)))
+//	db.Use(&tenancy.Plugin{Strict: true})
+//
+// Tenants requiring physical isolation get their own Postgres schema or
+// database instead, through a SchemaStrategy or DatabaseStrategy bound to
+// each request by the middleware. A Manager provisions, migrates and
+// deprovisions them at runtime:
+//
+//	strategy := tenancy.NewSchemaStrategy(db)
+//	manager := tenancy.NewManager(db, strategy)
+//	engine.Use(authMiddleware.TokenAuth(), tenancy.Middleware(tenancy.WithStrategy(strategy)))
+package tenancy
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrTenantRequired is returned when no tenant is resolved for a request, or,
+	// in strict mode, when a tenant scoped query runs without a tenant.
+	ErrTenantRequired = errors.New("tenant required")
+	// ErrTenantMismatch is returned when creating an entity for another tenant.
+	ErrTenantMismatch = errors.New("entity belongs to another tenant")
+	// ErrTenantNotAllowed is returned when the resolved tenant is not the tenant
+	// of the authenticated principal.
+	ErrTenantNotAllowed = errors.New("tenant not allowed for principal")
+	// ErrTenantUnverified is returned when the tenant of an unauthenticated
+	// request cannot be verified.
+	ErrTenantUnverified = errors.New("tenant of unauthenticated request not verified")
+)
+
+type (
+	tenantKey     struct{}
+	skipTenantKey struct{}
+)
+
+// ContextWithTenant returns a copy of ctx carrying the tenant ID.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// FromContext returns the tenant ID carried by ctx.
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID, tenantID != ""
+}
+
+// SkipTenant returns a copy of ctx in which queries are not restricted to a
+// tenant, even in strict mode, e.g. for migrations, seeders and jobs spanning
+// all tenants.
+func SkipTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipTenantKey{}, true)
+}
+
+func skipsTenant(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipTenantKey{}).(bool)
+	return skip
+}
+
+// CacheScope returns the tenant carried by ctx, or "" when ctx carries none or
+// came from SkipTenant. Pass it to repository.WithCacheScope so cached entries
+// are partitioned by tenant.
+func CacheScope(ctx context.Context) string {
+	if skipsTenant(ctx) {
+		return ""
+	}
+	tenantID, _ := FromContext(ctx)
+	return tenantID
+}
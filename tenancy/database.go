@@ -0,0 +1,171 @@
+package tenancy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/repository"
+	"gorm.io/gorm"
+)
+
+// DefaultDatabasePrefix prefixes the tenant ID in the name of its database.
+const DefaultDatabasePrefix = "tenant_"
+
+// DatabaseOptions configures a DatabaseStrategy.
+type DatabaseOptions struct {
+	// Admin creates and drops the tenant databases. Without it, provisioning
+	// only opens the database, which suits SQLite files and databases created
+	// out of band, found by Exists.
+	Admin *gorm.DB
+	// Prefix of the database names, DefaultDatabasePrefix by default. The
+	// DSN of the registry's opener must name the same database.
+	Prefix string
+	// Exists reports whether the database of a tenant is provisioned, checked
+	// before its pool is opened. By default the catalog of the Postgres or MySQL
+	// admin database is queried; without either, tenants not opened by
+	// Provision are rejected with ErrTenantUnchecked.
+	Exists func(ctx context.Context, tenantID string) (bool, error)
+}
+
+type DatabaseOption func(*DatabaseOptions)
+
+// WithAdminDB sets the database used to create and drop tenant databases.
+func WithAdminDB(admin *gorm.DB) DatabaseOption {
+	return func(o *DatabaseOptions) {
+		o.Admin = admin
+	}
+}
+
+// WithDatabaseExists sets the function reporting whether the database of a
+// tenant is provisioned, e.g. by looking for its SQLite file.
+func WithDatabaseExists(exists func(ctx context.Context, tenantID string) (bool, error)) DatabaseOption {
+	return func(o *DatabaseOptions) {
+		o.Exists = exists
+	}
+}
+
+// WithDatabasePrefix sets the prefix of the database names.
+func WithDatabasePrefix(prefix string) DatabaseOption {
+	return func(o *DatabaseOptions) {
+		o.Prefix = prefix
+	}
+}
+
+// DatabaseStrategy keeps each tenant in its own database, whose pool is taken
+// from a Registry.
+type DatabaseStrategy struct {
+	registry *Registry
+	options  DatabaseOptions
+}
+
+// NewDatabaseStrategy returns the database per tenant strategy.
+func NewDatabaseStrategy(registry *Registry, opts ...DatabaseOption) *DatabaseStrategy {
+	options := DatabaseOptions{Prefix: DefaultDatabasePrefix}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &DatabaseStrategy{registry: registry, options: options}
+}
+
+// Registry returns the registry of tenant pools.
+func (s *DatabaseStrategy) Registry() *Registry {
+	return s.registry
+}
+
+// Database returns the name of the database of tenantID.
+func (s *DatabaseStrategy) Database(tenantID string) (string, error) {
+	return identifier(s.options.Prefix, tenantID)
+}
+
+// Bind implements Strategy. The tenant's pool stays open until fn returns.
+func (s *DatabaseStrategy) Bind(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error {
+	name, err := s.Database(tenantID)
+	if err != nil {
+		return err
+	}
+	if !s.registry.Open(tenantID) {
+		exists, err := s.exists(ctx, tenantID, name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrUnknownTenant, tenantID)
+		}
+	}
+	conn, release, err := s.registry.Acquire(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(repository.ContextWithConn(ctx, conn))
+}
+
+// exists reports whether the database name of tenantID is provisioned.
+func (s *DatabaseStrategy) exists(ctx context.Context, tenantID, name string) (bool, error) {
+	if s.options.Exists != nil {
+		return s.options.Exists(ctx, tenantID)
+	}
+	admin := s.options.Admin
+	if admin == nil {
+		return false, ErrTenantUnchecked
+	}
+	var count int64
+	switch admin.Dialector.Name() {
+	case db.DriverPostgres:
+		err := admin.WithContext(ctx).Raw("SELECT count(*) FROM pg_database WHERE datname = ?", name).Scan(&count).Error
+		return count > 0, err
+	case db.DriverMySQL:
+		err := admin.WithContext(ctx).Raw("SELECT count(*) FROM information_schema.schemata WHERE schema_name = ?", name).Scan(&count).Error
+		return count > 0, err
+	}
+	return false, fmt.Errorf("%w: %s admin database", ErrTenantUnchecked, admin.Dialector.Name())
+}
+
+// Provision implements Strategy.
+func (s *DatabaseStrategy) Provision(ctx context.Context, tenantID string) error {
+	name, err := s.Database(tenantID)
+	if err != nil {
+		return err
+	}
+	if admin := s.options.Admin; admin != nil {
+		admin = admin.WithContext(ctx)
+		switch admin.Dialector.Name() {
+		case db.DriverPostgres:
+			// Postgres has no CREATE DATABASE IF NOT EXISTS.
+			var count int64
+			if err := admin.Raw("SELECT count(*) FROM pg_database WHERE datname = ?", name).Scan(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				err = admin.Exec("CREATE DATABASE ?", db.QuotedNames(name)).Error
+			}
+		case db.DriverMySQL:
+			err = admin.Exec("CREATE DATABASE IF NOT EXISTS ?", db.QuotedNames(name)).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = s.registry.Get(ctx, tenantID)
+	return err
+}
+
+// Deprovision implements Strategy. Without an admin database the tenant's pool
+// is closed but its database is kept.
+func (s *DatabaseStrategy) Deprovision(ctx context.Context, tenantID string) error {
+	name, err := s.Database(tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.registry.Remove(tenantID); err != nil {
+		return err
+	}
+	if admin := s.options.Admin; admin != nil {
+		switch admin.Dialector.Name() {
+		case db.DriverPostgres, db.DriverMySQL:
+			return admin.WithContext(ctx).Exec("DROP DATABASE IF EXISTS ?", db.QuotedNames(name)).Error
+		}
+	}
+	return nil
+}
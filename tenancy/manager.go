@@ -0,0 +1,293 @@
+package tenancy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/ebrickdev/ebrick/repository"
+	"gorm.io/gorm"
+)
+
+// DefaultTenantMigrationTable records the applied tenant migrations, apart from
+// the application migrations. Tenants sharing the primary database, as with
+// SharedStrategy, each get their own table suffixed with the tenant ID, e.g.
+// tenant_schema_migrations_acme.
+const DefaultTenantMigrationTable = "tenant_schema_migrations"
+
+// MigrationSource is implemented by modules contributing migrations applied
+// to the schema or database of every tenant.
+type MigrationSource interface {
+	TenantMigrations() ([]db.Migration, error)
+}
+
+// ManagerOptions configures a Manager.
+type ManagerOptions struct {
+	// Migrator options of the per tenant migrators, recording the applied
+	// migrations in DefaultTenantMigrationTable by default.
+	Migrator []db.MigratorOption
+	// ListTenants returns the provisioned tenants migrated by MigrateAll.
+	ListTenants func(ctx context.Context) ([]string, error)
+}
+
+type ManagerOption func(*ManagerOptions)
+
+// WithTenantMigratorOptions sets the options of the per tenant migrators.
+func WithTenantMigratorOptions(opts ...db.MigratorOption) ManagerOption {
+	return func(o *ManagerOptions) {
+		o.Migrator = append(o.Migrator, opts...)
+	}
+}
+
+// WithTenantLister sets the function listing the tenants migrated by MigrateAll.
+func WithTenantLister(list func(ctx context.Context) ([]string, error)) ManagerOption {
+	return func(o *ManagerOptions) {
+		o.ListTenants = list
+	}
+}
+
+// Manager provisions, migrates and deprovisions tenants at runtime.
+type Manager struct {
+	db       *gorm.DB
+	strategy Strategy
+	options  ManagerOptions
+
+	mu         sync.RWMutex
+	modules    []string
+	migrations map[string][]db.Migration
+}
+
+// NewManager returns a manager of the tenants isolated by strategy. primary is
+// used for the tenants of strategies not binding a database of their own, such
+// as SharedStrategy.
+func NewManager(primary *gorm.DB, strategy Strategy, opts ...ManagerOption) *Manager {
+	options := ManagerOptions{Migrator: []db.MigratorOption{db.WithMigrationTable(DefaultTenantMigrationTable)}}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Manager{db: primary, strategy: strategy, options: options, migrations: make(map[string][]db.Migration)}
+}
+
+// Strategy returns the isolation strategy of the tenants.
+func (m *Manager) Strategy() Strategy {
+	return m.strategy
+}
+
+// Register adds the migrations of module applied to every tenant.
+func (m *Manager) Register(module string, migrations ...db.Migration) error {
+	// Validate them as the tenant migrators will.
+	if err := db.NewMigrator(m.db).Register(module, migrations...); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.migrations[module]; !ok {
+		m.modules = append(m.modules, module)
+	}
+	m.migrations[module] = append(append([]db.Migration(nil), m.migrations[module]...), migrations...)
+	return nil
+}
+
+// Run calls fn with a copy of ctx bound to tenantID; see Run.
+func (m *Manager) Run(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error {
+	return Run(ctx, m.strategy, tenantID, fn)
+}
+
+// Provision creates the storage of tenantID and applies its migrations.
+func (m *Manager) Provision(ctx context.Context, tenantID string) ([]db.MigrationStatus, error) {
+	if err := m.strategy.Provision(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("provision tenant %s: %w", tenantID, err)
+	}
+	logger.FromContext(ctx).Info("Tenant provisioned", logger.TenantIDField(tenantID))
+	return m.Migrate(ctx, tenantID)
+}
+
+// Deprovision drops the storage of tenantID with all its data.
+func (m *Manager) Deprovision(ctx context.Context, tenantID string) error {
+	if err := m.strategy.Deprovision(ctx, tenantID); err != nil {
+		return fmt.Errorf("deprovision tenant %s: %w", tenantID, err)
+	}
+	if err := m.dropSharedMigrationTable(ctx, tenantID); err != nil {
+		return fmt.Errorf("deprovision tenant %s: %w", tenantID, err)
+	}
+	logger.FromContext(ctx).Info("Tenant deprovisioned", logger.TenantIDField(tenantID))
+	return nil
+}
+
+// Migrate applies the pending migrations of tenantID and returns them.
+func (m *Manager) Migrate(ctx context.Context, tenantID string) ([]db.MigrationStatus, error) {
+	var applied []db.MigrationStatus
+	err := m.migrator(ctx, tenantID, func(ctx context.Context, migrator *db.Migrator) (err error) {
+		applied, err = migrator.Up(ctx)
+		return err
+	})
+	if err != nil {
+		return applied, fmt.Errorf("migrate tenant %s: %w", tenantID, err)
+	}
+	if len(applied) > 0 {
+		logger.FromContext(ctx).Info("Tenant migrations applied", logger.TenantIDField(tenantID), logger.Int("count", len(applied)))
+	}
+	return applied, nil
+}
+
+// Status returns the migration status of tenantID.
+func (m *Manager) Status(ctx context.Context, tenantID string) ([]db.MigrationStatus, error) {
+	var status []db.MigrationStatus
+	err := m.migrator(ctx, tenantID, func(ctx context.Context, migrator *db.Migrator) (err error) {
+		status, err = migrator.Status(ctx)
+		return err
+	})
+	return status, err
+}
+
+// MigrateAll migrates the tenants returned by the tenant lister, continuing
+// past tenants that fail.
+func (m *Manager) MigrateAll(ctx context.Context) error {
+	if m.options.ListTenants == nil {
+		return nil
+	}
+	tenants, err := m.options.ListTenants(ctx)
+	if err != nil {
+		return fmt.Errorf("list tenants: %w", err)
+	}
+	var errs []error
+	for _, tenantID := range tenants {
+		if _, err := m.Migrate(ctx, tenantID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// migrator calls fn with a migrator of the tenant's database holding the
+// registered migrations.
+func (m *Manager) migrator(ctx context.Context, tenantID string, fn func(ctx context.Context, migrator *db.Migrator) error) error {
+	return m.Run(ctx, tenantID, func(ctx context.Context) error {
+		opts := m.options.Migrator
+		conn, ok := repository.ConnFromContext(ctx)
+		if !ok {
+			// The primary database is shared; record the tenant's migrations
+			// in a table of its own.
+			table, err := m.sharedMigrationTable(tenantID)
+			if err != nil {
+				return err
+			}
+			conn = m.db
+			opts = append(opts[:len(opts):len(opts)], db.WithMigrationTable(table))
+		}
+		migrator := db.NewMigrator(conn, opts...)
+		m.mu.RLock()
+		for _, module := range m.modules {
+			if err := migrator.Register(module, m.migrations[module]...); err != nil {
+				m.mu.RUnlock()
+				return err
+			}
+		}
+		m.mu.RUnlock()
+		return fn(ctx, migrator)
+	})
+}
+
+// sharedMigrationTable returns the migration table of tenantID on the primary
+// database.
+func (m *Manager) sharedMigrationTable(tenantID string) (string, error) {
+	options := db.MigratorOptions{Table: db.DefaultMigrationTable}
+	for _, o := range m.options.Migrator {
+		o(&options)
+	}
+	return identifier(options.Table+"_", tenantID)
+}
+
+// dropSharedMigrationTable drops the migration table of tenantID on the
+// primary database under SharedStrategy, so that the tenant is migrated afresh
+// when provisioned again.
+func (m *Manager) dropSharedMigrationTable(ctx context.Context, tenantID string) error {
+	if _, ok := m.strategy.(*SharedStrategy); !ok {
+		return nil
+	}
+	table, err := m.sharedMigrationTable(tenantID)
+	if err != nil {
+		return err
+	}
+	return m.db.WithContext(ctx).Migrator().DropTable(table)
+}
+
+type tenantRequest struct {
+	Tenant string `json:"tenant"`
+}
+
+type tenantPayload struct {
+	Tenant     string               `json:"tenant"`
+	Migrations []db.MigrationStatus `json:"migrations,omitempty"`
+}
+
+// ServeHTTP exposes the manager as an admin endpoint.
+//
+//	GET    ?tenant=acme       returns the migration status of the tenant
+//	POST   {"tenant":"acme"}  provisions and migrates the tenant
+//	PUT    {"tenant":"acme"}  migrates the tenant
+//	DELETE {"tenant":"acme"}  deprovisions the tenant
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req tenantRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Tenant = r.URL.Query().Get("tenant")
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeTenantError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT, DELETE")
+		writeTenantError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	if req.Tenant == "" {
+		writeTenantError(w, http.StatusBadRequest, ErrTenantRequired)
+		return
+	}
+
+	ctx := r.Context()
+	payload := tenantPayload{Tenant: req.Tenant}
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		payload.Migrations, err = m.Status(ctx, req.Tenant)
+	case http.MethodPost:
+		payload.Migrations, err = m.Provision(ctx, req.Tenant)
+	case http.MethodPut:
+		payload.Migrations, err = m.Migrate(ctx, req.Tenant)
+	case http.MethodDelete:
+		err = m.Deprovision(ctx, req.Tenant)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("Tenant request failed", logger.TenantIDField(req.Tenant), logger.Error(err))
+		writeTenantError(w, statusOf(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// statusOf returns the HTTP status of a tenant error.
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidTenant):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnknownTenant):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeTenantError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,176 @@
+package tenancy
+
+import (
+	"context"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/ebrickdev/ebrick/transport/http"
+)
+
+const (
+	// TenantIDKey is the gin context key of the resolved tenant ID.
+	TenantIDKey = "tenant_id"
+	// DefaultTenantClaim is the principal claim holding the tenant ID.
+	DefaultTenantClaim = "tenant_id"
+)
+
+// Options configures the tenancy middleware.
+type Options struct {
+	// Resolvers are tried in order; the first tenant ID found wins.
+	Resolvers []Resolver
+	// Optional lets requests without a tenant through instead of rejecting them with 400.
+	Optional bool
+	// Claim names the claim of the authenticated principal holding its tenant; a
+	// request resolved to another tenant, or whose principal lacks the claim, is
+	// rejected with 403. Empty disables the check.
+	Claim string
+	// Unverified accepts tenants of unauthenticated requests without a
+	// validator, although the client chose them, e.g. in a header.
+	Unverified bool
+	// Validate rejects unknown or disabled tenants with 403 when it returns an error.
+	Validate func(ctx context.Context, tenantID string) error
+	// Strategy binds the request to the tenant's schema or database; unknown
+	// tenants are rejected with 404.
+	Strategy Strategy
+}
+
+type Option func(*Options)
+
+// WithResolvers sets the resolvers tried in order.
+func WithResolvers(resolvers ...Resolver) Option {
+	return func(o *Options) {
+		o.Resolvers = append(o.Resolvers, resolvers...)
+	}
+}
+
+// WithOptionalTenant lets requests without a tenant through.
+func WithOptionalTenant() Option {
+	return func(o *Options) {
+		o.Optional = true
+	}
+}
+
+// WithTenantClaim sets the principal claim the resolved tenant must match;
+// "" disables the check.
+func WithTenantClaim(claim string) Option {
+	return func(o *Options) {
+		o.Claim = claim
+	}
+}
+
+// WithUnverifiedTenants accepts the tenant of unauthenticated requests even
+// without a validator, for services trusting their clients, e.g. behind a
+// gateway that sets the tenant header.
+func WithUnverifiedTenants() Option {
+	return func(o *Options) {
+		o.Unverified = true
+	}
+}
+
+// WithValidator sets the function rejecting unknown or disabled tenants.
+func WithValidator(validate func(ctx context.Context, tenantID string) error) Option {
+	return func(o *Options) {
+		o.Validate = validate
+	}
+}
+
+// WithStrategy binds each request to the storage of its tenant.
+func WithStrategy(strategy Strategy) Option {
+	return func(o *Options) {
+		o.Strategy = strategy
+	}
+}
+
+// Middleware resolves the tenant of each request and stores it in the request
+// context, where FromContext and the GORM plugin find it, and in the
+// request-scoped logger. Without resolvers the tenant is read from the
+// http.TenantIDHeader header. Run it after the auth middleware: the tenant of
+// an authenticated request must be the one in the principal's
+// DefaultTenantClaim, so a header or subdomain cannot switch a user to another
+// tenant. Unauthenticated requests are rejected with 403 unless a validator
+// checks their tenant or WithUnverifiedTenants is set. With a strategy the
+// rest of the chain runs bound to the tenant's storage.
+func Middleware(opts ...Option) http.HandlerFunc {
+	options := Options{Claim: DefaultTenantClaim}
+	for _, o := range opts {
+		o(&options)
+	}
+	if len(options.Resolvers) == 0 {
+		options.Resolvers = []Resolver{HeaderResolver(http.TenantIDHeader)}
+	}
+
+	return func(c *http.Context) {
+		var tenantID string
+		for _, resolve := range options.Resolvers {
+			if tenantID = resolve(c); tenantID != "" {
+				break
+			}
+		}
+		if tenantID == "" {
+			if !options.Optional {
+				c.AbortWithError(http.StatusBadRequest, ErrTenantRequired)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		if err := options.verify(c, tenantID); err != nil {
+			logger.FromContext(ctx).Warn("Tenant rejected", logger.TenantIDField(tenantID), logger.Error(err))
+			c.AbortWithError(http.StatusForbidden, err)
+			return
+		}
+		if options.Validate != nil {
+			if err := options.Validate(ctx, tenantID); err != nil {
+				logger.FromContext(ctx).Warn("Tenant rejected", logger.TenantIDField(tenantID), logger.Error(err))
+				c.AbortWithError(http.StatusForbidden, err)
+				return
+			}
+		}
+
+		c.Set(TenantIDKey, tenantID)
+		ctx = ContextWithTenant(ctx, tenantID)
+		ctx = logger.IntoContext(ctx, logger.FromContext(ctx).With(logger.TenantIDField(tenantID)))
+		if options.Strategy == nil {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		err := options.Strategy.Bind(ctx, tenantID, func(ctx context.Context) error {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		status := statusOf(err)
+		if status == http.StatusInternalServerError || c.Writer.Written() {
+			logger.FromContext(ctx).Error("Failed to bind tenant storage", logger.Error(err))
+		} else {
+			logger.FromContext(ctx).Warn("Tenant rejected", logger.Error(err))
+		}
+		if !c.Writer.Written() {
+			c.AbortWithError(status, err)
+		}
+	}
+}
+
+// verify checks that the client may act for tenantID: an authenticated
+// principal must hold it in its claim, and tenants of unauthenticated requests
+// need a validator or WithUnverifiedTenants.
+func (o *Options) verify(c *http.Context, tenantID string) error {
+	if _, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+		if o.Claim != "" && principalTenant(c, o.Claim) != tenantID {
+			return ErrTenantNotAllowed
+		}
+		return nil
+	}
+	if o.Validate == nil && !o.Unverified {
+		return ErrTenantUnverified
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/ebrickdev/ebrick/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type principal struct{ claims map[string]interface{} }
+
+func (p principal) GetID() string                     { return "user-1" }
+func (p principal) GetEmail() string                  { return "" }
+func (p principal) GetRoles() []string                { return nil }
+func (p principal) GetClaims() map[string]interface{} { return p.claims }
+
+// serve runs the middleware for a request to target and returns the status and
+// the tenant found in the handler's context.
+func serve(t *testing.T, target string, prepare func(c *http.Context), opts ...Option) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var tenantID string
+	engine := gin.New()
+	if prepare != nil {
+		engine.Use(prepare)
+	}
+	engine.Use(Middleware(opts...))
+	handler := func(c *http.Context) {
+		tenantID, _ = FromContext(c.Request.Context())
+	}
+	engine.GET("/", handler)
+	engine.GET("/tenants/:tenant/orders", handler)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set(http.TenantIDHeader, "from-header")
+	engine.ServeHTTP(rec, req)
+	return rec.Code, tenantID
+}
+
+func TestMiddlewareResolvers(t *testing.T) {
+	withClaims := func(c *http.Context) {
+		p := principal{claims: map[string]interface{}{"tenant_id": "from-claim"}}
+		c.Request = c.Request.WithContext(auth.ContextWithPrincipal(c.Request.Context(), p))
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		prepare func(c *http.Context)
+		opts    []Option
+		want    string
+	}{
+		{"default header", "/", nil, []Option{WithUnverifiedTenants()}, "from-header"},
+		{"subdomain", "http://acme.example.com:8080/", nil, []Option{WithResolvers(SubdomainResolver("example.com")), WithUnverifiedTenants()}, "acme"},
+		{"claim", "/", withClaims, []Option{WithResolvers(ClaimResolver("tenant_id"), HeaderResolver(http.TenantIDHeader))}, "from-claim"},
+		{"fallback", "/", nil, []Option{WithResolvers(ClaimResolver("tenant_id"), HeaderResolver(http.TenantIDHeader)), WithUnverifiedTenants()}, "from-header"},
+		{"path", "/tenants/globex/orders", nil, []Option{WithResolvers(PathResolver("tenant")), WithUnverifiedTenants()}, "globex"},
+		{"validated", "/", nil, []Option{WithValidator(func(ctx context.Context, tenantID string) error { return nil })}, "from-header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, tenantID := serve(t, tt.target, tt.prepare, tt.opts...)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.want, tenantID)
+		})
+	}
+}
+
+func TestMiddlewareRejects(t *testing.T) {
+	code, _ := serve(t, "http://example.com/", nil, WithResolvers(SubdomainResolver("example.com")))
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, tenantID := serve(t, "http://example.com/", nil, WithResolvers(SubdomainResolver("example.com")), WithOptionalTenant())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, tenantID)
+
+	code, _ = serve(t, "/", nil, WithValidator(func(ctx context.Context, tenantID string) error {
+		return errors.New("unknown tenant")
+	}))
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, tenantID = serve(t, "http://acme.example.com/", nil, WithResolvers(SubdomainResolver("example.com")))
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Empty(t, tenantID)
+}
+
+func TestMiddlewareRejectsOtherTenantThanClaim(t *testing.T) {
+	withClaims := func(c *http.Context) {
+		p := principal{claims: map[string]interface{}{"tenant_id": "from-claim"}}
+		c.Request = c.Request.WithContext(auth.ContextWithPrincipal(c.Request.Context(), p))
+	}
+
+	code, tenantID := serve(t, "/", withClaims)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Empty(t, tenantID)
+
+	code, _ = serve(t, "/tenants/from-claim/orders", withClaims, WithResolvers(PathResolver("tenant")))
+	assert.Equal(t, http.StatusOK, code)
+
+	code, tenantID = serve(t, "/", withClaims, WithTenantClaim(""))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "from-header", tenantID)
+
+	withoutClaim := func(c *http.Context) {
+		c.Request = c.Request.WithContext(auth.ContextWithPrincipal(c.Request.Context(), principal{}))
+	}
+	code, tenantID = serve(t, "/", withoutClaim, WithUnverifiedTenants())
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Empty(t, tenantID)
+}
+
+func TestSubdomainResolver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resolve := SubdomainResolver(".example.com")
+	for host, want := range map[string]string{
+		"acme.example.com":     "acme",
+		"ACME.Example.com:443": "acme",
+		"example.com":          "",
+		"a.b.example.com":      "",
+		"acme.other.com":       "",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		c.Request.Host = host
+		assert.Equal(t, want, resolve(c), host)
+	}
+}
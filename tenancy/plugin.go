@@ -0,0 +1,136 @@
+package tenancy
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tenantField is the field of entity.Tenanted.
+const tenantField = "TenantID"
+
+// Plugin is a GORM plugin isolating the data of entities embedding
+// entity.Tenanted by the tenant in the statement context. Creates stamp
+// TenantID, updates keep it, and queries, updates and deletes are restricted
+// to the tenant's rows. Statements without a tenant are not restricted unless
+// Strict is set, in which case they fail with ErrTenantRequired. Contexts from
+// SkipTenant are never restricted.
+type Plugin struct {
+	Strict bool
+}
+
+// Name implements gorm.Plugin.
+func (p *Plugin) Name() string {
+	return "ebrick:tenancy"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("ebrick:tenancy_create", p.beforeCreate); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("ebrick:tenancy_query", p.restrict); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("ebrick:tenancy_row", p.restrict); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("ebrick:tenancy_update", p.beforeUpdate); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("ebrick:tenancy_delete", p.restrict)
+}
+
+// Scope restricts a query to the rows of tenantID, for statements the plugin
+// does not see, e.g. on a database without it:
+//
+//	db.Scopes(tenancy.Scope(tenantID)).Find(&orders)
+func Scope(tenantID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(condition("tenant_id", tenantID))
+	}
+}
+
+// tenant returns the tenant field of the statement's model and the tenant of
+// its context. ok is false when the statement is not restricted.
+func (p *Plugin) tenant(db *gorm.DB) (field *schema.Field, tenantID string, ok bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || skipsTenant(stmt.Context) {
+		return nil, "", false
+	}
+	if field = stmt.Schema.LookUpField(tenantField); field == nil {
+		return nil, "", false
+	}
+	tenantID, found := FromContext(stmt.Context)
+	if !found {
+		if p.Strict {
+			_ = db.AddError(fmt.Errorf("%w: %s", ErrTenantRequired, stmt.Schema.Table))
+		}
+		return nil, "", false
+	}
+	return field, tenantID, true
+}
+
+func (p *Plugin) beforeCreate(db *gorm.DB) {
+	field, tenantID, ok := p.tenant(db)
+	if !ok {
+		return
+	}
+	ctx := db.Statement.Context
+	stamp := func(rv reflect.Value) {
+		current, zero := field.ValueOf(ctx, rv)
+		if zero {
+			_ = db.AddError(field.Set(ctx, rv, tenantID))
+		} else if current != tenantID {
+			_ = db.AddError(fmt.Errorf("%w: %v", ErrTenantMismatch, current))
+		}
+	}
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				stamp(elem)
+			}
+		}
+	case reflect.Struct:
+		stamp(rv)
+	}
+
+	// Upserts, including Save of a row the tenant cannot see, must not update
+	// the conflicting row of another tenant.
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+				Column: clause.Column{Table: db.Statement.Table, Name: field.DBName},
+				Value:  tenantID,
+			})
+			db.Statement.AddClause(onConflict)
+		}
+	}
+}
+
+func (p *Plugin) beforeUpdate(db *gorm.DB) {
+	field, tenantID, ok := p.tenant(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition(field.DBName, tenantID)}})
+	// Saving an entity loaded without the field must not move it to another tenant.
+	db.Statement.SetColumn(field.DBName, tenantID, true)
+}
+
+func (p *Plugin) restrict(db *gorm.DB) {
+	field, tenantID, ok := p.tenant(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition(field.DBName, tenantID)}})
+}
+
+func condition(column, tenantID string) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: tenantID}
+}
@@ -0,0 +1,136 @@
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/entity"
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type invoice struct {
+	ID    int64
+	Total int
+	entity.Tenanted
+}
+
+type currency struct {
+	Code string `gorm:"primaryKey"`
+}
+
+func openTestDB(t *testing.T, plugin *Plugin) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&invoice{}, &currency{}))
+	require.NoError(t, db.Use(plugin))
+	return db
+}
+
+func TestPluginIsolatesTenants(t *testing.T) {
+	db := openTestDB(t, &Plugin{})
+	repo := repository.NewCrudRepository[invoice, int64](db)
+	acme := ContextWithTenant(context.Background(), "acme")
+	globex := ContextWithTenant(context.Background(), "globex")
+
+	a, err := repo.Create(acme, &invoice{Total: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", a.TenantID)
+	_, err = repo.Create(globex, &invoice{Total: 20})
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateMany(globex, []invoice{{Total: 30}, {Total: 40}}))
+
+	all, err := repo.ListAll(acme)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+	count, err := repo.CountBySpec(globex, repository.NewSpec())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	_, err = repo.FindByID(globex, a.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	require.NoError(t, repo.Delete(globex, a.ID))
+	_, err = repo.FindByID(acme, a.ID)
+	require.NoError(t, err, "another tenant cannot delete the invoice")
+
+	// Saving another tenant's row neither updates nor takes it over.
+	_, err = repo.Update(globex, &invoice{ID: a.ID, Total: 99})
+	require.NoError(t, err)
+	found, err := repo.FindByID(acme, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10, found.Total)
+	assert.Equal(t, "acme", found.TenantID)
+
+	// Saving without the tenant field keeps the row with its tenant.
+	_, err = repo.Update(acme, &invoice{ID: a.ID, Total: 11})
+	require.NoError(t, err)
+	found, err = repo.FindByID(acme, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 11, found.Total)
+
+	_, err = repo.Create(acme, &invoice{Total: 1, Tenanted: entity.Tenanted{TenantID: "globex"}})
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+
+	// Without a tenant nothing is restricted unless strict.
+	all, err = repo.ListAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	var scoped []invoice
+	require.NoError(t, db.Session(&gorm.Session{}).Scopes(Scope("globex")).Find(&scoped).Error)
+	assert.Len(t, scoped, 3)
+}
+
+func TestPluginStrict(t *testing.T) {
+	db := openTestDB(t, &Plugin{Strict: true})
+	repo := repository.NewCrudRepository[invoice, int64](db)
+	ctx := context.Background()
+
+	_, err := repo.ListAll(ctx)
+	assert.ErrorIs(t, err, ErrTenantRequired)
+	_, err = repo.Create(ctx, &invoice{Total: 1})
+	assert.ErrorIs(t, err, ErrTenantRequired)
+
+	_, err = repo.Create(ContextWithTenant(ctx, "acme"), &invoice{Total: 1})
+	require.NoError(t, err)
+	all, err := repo.ListAll(SkipTenant(ctx))
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// Entities without the mixin are not affected.
+	require.NoError(t, db.WithContext(ctx).Create(&currency{Code: "EUR"}).Error)
+	var currencies []currency
+	require.NoError(t, db.WithContext(ctx).Find(&currencies).Error)
+	assert.Len(t, currencies, 1)
+}
+
+func TestPluginIsolatesReplicaReads(t *testing.T) {
+	primary := openTestDB(t, &Plugin{Strict: true})
+	replica := openTestDB(t, &Plugin{Strict: true})
+	repo := repository.NewCrudRepository[invoice, int64](primary, repository.WithReadDB(replica))
+	acme := ContextWithTenant(context.Background(), "acme")
+
+	// The replica holds rows of both tenants, as replicated from the primary.
+	require.NoError(t, replica.WithContext(SkipTenant(acme)).Create([]invoice{
+		{ID: 1, Total: 10, Tenanted: entity.Tenanted{TenantID: "acme"}},
+		{ID: 2, Total: 20, Tenanted: entity.Tenanted{TenantID: "globex"}},
+	}).Error)
+
+	all, err := repo.ListAll(acme)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "acme", all[0].TenantID)
+	_, err = repo.FindByID(acme, 2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repo.ListAll(context.Background())
+	assert.ErrorIs(t, err, ErrTenantRequired)
+}
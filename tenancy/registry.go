@@ -0,0 +1,266 @@
+package tenancy
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ebrickdev/ebrick/db"
+	"gorm.io/gorm"
+)
+
+// TenantPlaceholder is replaced by the tenant ID in the DSN given to DSNOpener.
+const TenantPlaceholder = "{tenant}"
+
+// OpenFunc opens the database of a tenant.
+type OpenFunc func(ctx context.Context, tenantID string) (*gorm.DB, error)
+
+// DSNOpener opens tenant databases with cfg, its DSN having TenantPlaceholder
+// replaced by the tenant ID, e.g. "host=db dbname=tenant_{tenant}".
+func DSNOpener(cfg db.DataSourceConfig) OpenFunc {
+	return func(ctx context.Context, tenantID string) (*gorm.DB, error) {
+		if _, err := identifier("", tenantID); err != nil {
+			return nil, err
+		}
+		tenantCfg := cfg
+		tenantCfg.DSN = strings.ReplaceAll(cfg.DSN, TenantPlaceholder, tenantID)
+		return db.Open(tenantCfg)
+	}
+}
+
+// DefaultMaxPools bounds the pools a Registry keeps open at once by default.
+const DefaultMaxPools = 100
+
+// RegistryOptions configures a Registry.
+type RegistryOptions struct {
+	// MaxPools bounds the pools open at once, DefaultMaxPools by default; 0
+	// means unbounded.
+	MaxPools int
+}
+
+type RegistryOption func(*RegistryOptions)
+
+// WithMaxPools evicts the least recently used pool when opening one more than
+// max. An evicted pool still acquired by a request is closed once released.
+func WithMaxPools(max int) RegistryOption {
+	return func(o *RegistryOptions) {
+		o.MaxPools = max
+	}
+}
+
+// Registry keeps a connection pool per tenant, opened on first use.
+type Registry struct {
+	open    OpenFunc
+	options RegistryOptions
+
+	mu      sync.Mutex
+	plugins []gorm.Plugin
+	pools   map[string]*list.Element
+	lru     *list.List // of *pool, most recently used first
+}
+
+type pool struct {
+	tenantID string
+	db       *gorm.DB
+	refs     int  // acquisitions not yet released
+	removed  bool // evicted or removed, closed on the last release
+}
+
+// NewRegistry returns a registry opening the tenant databases with open.
+func NewRegistry(open OpenFunc, opts ...RegistryOption) *Registry {
+	options := RegistryOptions{MaxPools: DefaultMaxPools}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Registry{open: open, options: options, pools: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Get returns the database of tenantID, opening it if needed. Use Acquire to
+// keep the pool open while using it.
+func (r *Registry) Get(ctx context.Context, tenantID string) (*gorm.DB, error) {
+	db, release, err := r.Acquire(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return db, nil
+}
+
+// Acquire returns the database of tenantID, opening it if needed, and keeps it
+// open until release is called, even when it is evicted or removed meanwhile.
+func (r *Registry) Acquire(ctx context.Context, tenantID string) (db *gorm.DB, release func(), err error) {
+	if p, ok := r.lookup(tenantID); ok {
+		return p.db, r.releaser(p), nil
+	}
+
+	// Open outside the lock so that a slow database does not hold up the others.
+	opened, err := r.open(ctx, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+	r.mu.Lock()
+	plugins := r.plugins
+	r.mu.Unlock()
+	if err := use(opened, plugins...); err != nil {
+		_ = closeDB(opened)
+		return nil, nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+
+	r.mu.Lock()
+	if e, ok := r.pools[tenantID]; ok {
+		r.lru.MoveToFront(e)
+		p := e.Value.(*pool)
+		p.refs++
+		r.mu.Unlock()
+		_ = closeDB(opened)
+		return p.db, r.releaser(p), nil
+	}
+	p := &pool{tenantID: tenantID, db: opened, refs: 1}
+	r.pools[tenantID] = r.lru.PushFront(p)
+	var evicted []*gorm.DB
+	for r.options.MaxPools > 0 && r.lru.Len() > r.options.MaxPools {
+		if db, ok := r.detach(r.lru.Back()); ok {
+			evicted = append(evicted, db)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, db := range evicted {
+		_ = closeDB(db)
+	}
+	return opened, r.releaser(p), nil
+}
+
+// lookup returns the open pool of tenantID, acquired.
+func (r *Registry) lookup(tenantID string) (*pool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.pools[tenantID]
+	if !ok {
+		return nil, false
+	}
+	r.lru.MoveToFront(e)
+	p := e.Value.(*pool)
+	p.refs++
+	return p, true
+}
+
+// detach removes the pool of e from the registry, returning its database when
+// it is not acquired and must be closed now. The caller holds r.mu.
+func (r *Registry) detach(e *list.Element) (*gorm.DB, bool) {
+	p := r.lru.Remove(e).(*pool)
+	delete(r.pools, p.tenantID)
+	p.removed = true
+	return p.db, p.refs == 0
+}
+
+// releaser returns the function releasing an acquisition of p, closing it if
+// it was removed from the registry meanwhile.
+func (r *Registry) releaser(p *pool) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			p.refs--
+			closing := p.removed && p.refs == 0
+			r.mu.Unlock()
+			if closing {
+				_ = closeDB(p.db)
+			}
+		})
+	}
+}
+
+// Use registers plugins on the open pools and on those opened later, e.g. the
+// Plugin and db.AuditingPlugin registered on the primary database.
+func (r *Registry) Use(plugins ...gorm.Plugin) error {
+	r.mu.Lock()
+	r.plugins = append(r.plugins[:len(r.plugins):len(r.plugins)], plugins...)
+	open := make([]*gorm.DB, 0, len(r.pools))
+	for _, e := range r.pools {
+		open = append(open, e.Value.(*pool).db)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, db := range open {
+		errs = append(errs, use(db, plugins...))
+	}
+	return errors.Join(errs...)
+}
+
+// use registers plugins on db, ignoring those already registered.
+func use(db *gorm.DB, plugins ...gorm.Plugin) error {
+	for _, plugin := range plugins {
+		if err := db.Use(plugin); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove closes the pool of tenantID, if open, or once released when acquired.
+func (r *Registry) Remove(tenantID string) error {
+	r.mu.Lock()
+	var (
+		db      *gorm.DB
+		closing bool
+	)
+	if e, ok := r.pools[tenantID]; ok {
+		db, closing = r.detach(e)
+	}
+	r.mu.Unlock()
+	if !closing {
+		return nil
+	}
+	return closeDB(db)
+}
+
+// Open reports whether the pool of tenantID is open.
+func (r *Registry) Open(tenantID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.pools[tenantID]
+	return ok
+}
+
+// Tenants returns the sorted IDs of the tenants with an open pool.
+func (r *Registry) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenants := make([]string, 0, len(r.pools))
+	for tenantID := range r.pools {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Close closes all pools, including acquired ones.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	pools := r.pools
+	r.pools = make(map[string]*list.Element)
+	r.lru.Init()
+	r.mu.Unlock()
+
+	var errs []error
+	for tenantID, e := range pools {
+		if err := closeDB(e.Value.(*pool).db); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
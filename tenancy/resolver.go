@@ -0,0 +1,69 @@
+package tenancy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/ebrickdev/ebrick/transport/http"
+)
+
+// Resolver returns the tenant ID of a request, or "" when it names none.
+type Resolver func(c *http.Context) string
+
+// HeaderResolver reads the tenant ID from the named header, e.g. http.TenantIDHeader.
+func HeaderResolver(name string) Resolver {
+	return func(c *http.Context) string {
+		return strings.TrimSpace(c.GetHeader(name))
+	}
+}
+
+// SubdomainResolver reads the tenant ID from the subdomain of baseDomain,
+// e.g. "acme" for acme.example.com with baseDomain example.com.
+func SubdomainResolver(baseDomain string) Resolver {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(c *http.Context) string {
+		host := strings.ToLower(c.Request.Host)
+		if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+			host = host[:i]
+		}
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// ClaimResolver reads the tenant ID from a claim of the authenticated
+// principal, so the auth middleware must run first.
+func ClaimResolver(claim string) Resolver {
+	return func(c *http.Context) string {
+		return principalTenant(c, claim)
+	}
+}
+
+// principalTenant returns the claim of the authenticated principal, or "" when
+// there is no principal or it lacks the claim.
+func principalTenant(c *http.Context, claim string) string {
+	principal, ok := auth.PrincipalFromContext(c.Request.Context())
+	if !ok {
+		return ""
+	}
+	switch v := principal.GetClaims()[claim].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// PathResolver reads the tenant ID from a route parameter, e.g. "tenant" for
+// the route /tenants/:tenant/orders.
+func PathResolver(param string) Resolver {
+	return func(c *http.Context) string {
+		return c.Param(param)
+	}
+}
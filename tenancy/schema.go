@@ -0,0 +1,159 @@
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/repository"
+	"gorm.io/gorm"
+)
+
+// DefaultSchemaPrefix prefixes the tenant ID in the name of its schema.
+const DefaultSchemaPrefix = "tenant_"
+
+// SchemaOptions configures a SchemaStrategy.
+type SchemaOptions struct {
+	// Prefix of the schema names, DefaultSchemaPrefix by default.
+	Prefix string
+	// Shared schemas follow the tenant schema in the search_path, e.g. "public"
+	// for extensions and enum types. Tables missing from the tenant schema are
+	// then looked up there.
+	Shared []string
+}
+
+type SchemaOption func(*SchemaOptions)
+
+// WithSchemaPrefix sets the prefix of the schema names.
+func WithSchemaPrefix(prefix string) SchemaOption {
+	return func(o *SchemaOptions) {
+		o.Prefix = prefix
+	}
+}
+
+// WithSharedSchemas appends schemas to the search_path after the tenant schema.
+func WithSharedSchemas(schemas ...string) SchemaOption {
+	return func(o *SchemaOptions) {
+		o.Shared = append(o.Shared, schemas...)
+	}
+}
+
+// SchemaStrategy keeps the tables of each tenant in its own Postgres schema.
+// Bind holds a connection for the duration of fn with its search_path set to
+// the tenant schema, so the tenant's tables are used without qualifying them.
+type SchemaStrategy struct {
+	db      *gorm.DB
+	options SchemaOptions
+
+	mu    sync.RWMutex
+	known map[string]bool
+}
+
+// NewSchemaStrategy returns the schema per tenant strategy for the Postgres db.
+func NewSchemaStrategy(db *gorm.DB, opts ...SchemaOption) *SchemaStrategy {
+	options := SchemaOptions{Prefix: DefaultSchemaPrefix}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &SchemaStrategy{db: db, options: options, known: make(map[string]bool)}
+}
+
+// Schema returns the name of the schema of tenantID.
+func (s *SchemaStrategy) Schema(tenantID string) (string, error) {
+	return identifier(s.options.Prefix, tenantID)
+}
+
+// Bind implements Strategy.
+func (s *SchemaStrategy) Bind(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error {
+	schema, err := s.schema(tenantID)
+	if err != nil {
+		return err
+	}
+	exists, err := s.exists(ctx, schema)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownTenant, tenantID)
+	}
+
+	return s.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		path := append([]string{schema}, s.options.Shared...)
+		if err := conn.Exec("SET search_path TO ?", db.QuotedNames(path...)).Error; err != nil {
+			return err
+		}
+		defer s.reset(ctx, conn)
+		return fn(repository.ContextWithConn(ctx, conn))
+	})
+}
+
+// reset restores the search_path before the connection returns to the pool,
+// or discards the connection when that fails, so that it is never reused with
+// the schema of the tenant.
+func (s *SchemaStrategy) reset(ctx context.Context, conn *gorm.DB) {
+	err := conn.WithContext(context.WithoutCancel(ctx)).Exec("RESET search_path").Error
+	if c, ok := conn.Statement.ConnPool.(*sql.Conn); ok && err != nil {
+		_ = c.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+// Provision implements Strategy.
+func (s *SchemaStrategy) Provision(ctx context.Context, tenantID string) error {
+	schema, err := s.schema(tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Exec("CREATE SCHEMA IF NOT EXISTS ?", db.QuotedNames(schema)).Error; err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.known[schema] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Deprovision implements Strategy.
+func (s *SchemaStrategy) Deprovision(ctx context.Context, tenantID string) error {
+	schema, err := s.schema(tenantID)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.known, schema)
+	s.mu.Unlock()
+	return s.db.WithContext(ctx).Exec("DROP SCHEMA IF EXISTS ? CASCADE", db.QuotedNames(schema)).Error
+}
+
+// schema returns the schema of tenantID, failing on databases without schemas.
+func (s *SchemaStrategy) schema(tenantID string) (string, error) {
+	if name := s.db.Dialector.Name(); name != db.DriverPostgres {
+		return "", fmt.Errorf("%w: schema per tenant needs postgres, not %s", db.ErrUnsupportedDriver, name)
+	}
+	return s.Schema(tenantID)
+}
+
+// exists reports whether schema exists. Existing schemas are remembered, so
+// only the first request of a tenant pays for the lookup.
+func (s *SchemaStrategy) exists(ctx context.Context, schema string) (bool, error) {
+	s.mu.RLock()
+	known := s.known[schema]
+	s.mu.RUnlock()
+	if known {
+		return true, nil
+	}
+
+	var count int64
+	err := s.db.WithContext(ctx).
+		Raw("SELECT count(*) FROM information_schema.schemata WHERE schema_name = ?", schema).
+		Scan(&count).Error
+	if err != nil || count == 0 {
+		return false, err
+	}
+	s.mu.Lock()
+	s.known[schema] = true
+	s.mu.Unlock()
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownTenant is returned when binding a tenant that is not provisioned.
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrInvalidTenant is returned for tenant IDs that cannot name a schema or database.
+	ErrInvalidTenant = errors.New("invalid tenant id")
+	// ErrTenantUnchecked is returned by a DatabaseStrategy that cannot tell
+	// whether the database of a tenant is provisioned.
+	ErrTenantUnchecked = errors.New("tenant database existence cannot be checked")
+)
+
+// tenantIDPattern restricts the tenant IDs used in schema and database names.
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// maxIdentifierLength is the longest schema or database name accepted by
+// Postgres; MySQL accepts 64 characters.
+const maxIdentifierLength = 63
+
+// Strategy isolates the data of tenants.
+type Strategy interface {
+	// Bind calls fn with a copy of ctx whose repositories read and write the
+	// data of tenantID. It returns ErrUnknownTenant when the tenant is not
+	// provisioned and the strategy can tell.
+	Bind(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error
+	// Provision creates the storage of tenantID, if it does not exist yet.
+	Provision(ctx context.Context, tenantID string) error
+	// Deprovision drops the storage of tenantID with all its data.
+	Deprovision(ctx context.Context, tenantID string) error
+}
+
+// SharedStrategy keeps the rows of all tenants in shared tables, isolated by
+// the Plugin through their TenantID column.
+type SharedStrategy struct {
+	db     *gorm.DB
+	models []any
+}
+
+// NewSharedStrategy returns the shared table strategy. Deprovisioning deletes
+// the rows of the tenant from the tables of models, which should embed
+// entity.Tenanted.
+func NewSharedStrategy(db *gorm.DB, models ...any) *SharedStrategy {
+	return &SharedStrategy{db: db, models: models}
+}
+
+// Bind implements Strategy; the context tenant set by Run or the middleware
+// is all the Plugin needs.
+func (s *SharedStrategy) Bind(ctx context.Context, tenantID string, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Provision implements Strategy; shared tables need no storage per tenant.
+func (s *SharedStrategy) Provision(ctx context.Context, tenantID string) error {
+	return nil
+}
+
+// Deprovision implements Strategy.
+func (s *SharedStrategy) Deprovision(ctx context.Context, tenantID string) error {
+	return s.db.WithContext(SkipTenant(ctx)).Transaction(func(tx *gorm.DB) error {
+		for _, model := range s.models {
+			if err := tx.Where(condition("tenant_id", tenantID)).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// identifier returns prefix+tenantID, the name of the schema or database of
+// the tenant.
+func identifier(prefix, tenantID string) (string, error) {
+	name := prefix + tenantID
+	if !tenantIDPattern.MatchString(tenantID) || len(name) > maxIdentifierLength {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenant, tenantID)
+	}
+	return name, nil
+}
+
+// Run calls fn with a copy of ctx carrying tenantID, bound to its data by
+// strategy, e.g. for jobs and consumers working for a tenant outside a request.
+func Run(ctx context.Context, strategy Strategy, tenantID string, fn func(ctx context.Context) error) error {
+	ctx = ContextWithTenant(ctx, tenantID)
+	ctx = logger.IntoContext(ctx, logger.FromContext(ctx).With(logger.TenantIDField(tenantID)))
+	return strategy.Bind(ctx, tenantID, fn)
+}
@@ -0,0 +1,314 @@
+package tenancy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/db"
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/ebrickdev/ebrick/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type note struct {
+	ID   int64
+	Text string
+}
+
+var noteMigrations = []db.Migration{
+	{Version: 1, Name: "create_notes", UpSQL: "CREATE TABLE notes (id integer PRIMARY KEY, text text)", DownSQL: "DROP TABLE notes"},
+}
+
+func newDatabaseManager(t *testing.T, opts ...RegistryOption) (*Manager, *DatabaseStrategy) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "tenant_"+TenantPlaceholder+".db")
+	registry := NewRegistry(DSNOpener(db.DataSourceConfig{Driver: db.DriverSQLite, DSN: dsn, LogLevel: "silent"}), opts...)
+	t.Cleanup(func() { _ = registry.Close() })
+	strategy := NewDatabaseStrategy(registry, WithDatabaseExists(func(ctx context.Context, tenantID string) (bool, error) {
+		_, err := os.Stat(strings.ReplaceAll(dsn, TenantPlaceholder, tenantID))
+		return err == nil, nil
+	}))
+	manager := NewManager(nil, strategy)
+	require.NoError(t, manager.Register("notes", noteMigrations...))
+	return manager, strategy
+}
+
+func TestDatabaseStrategy(t *testing.T) {
+	manager, strategy := newDatabaseManager(t)
+	// Repositories are created on the primary database, which has no notes.
+	primary := openTestDB(t, &Plugin{})
+	repo := repository.NewCrudRepository[note, int64](primary)
+	ctx := context.Background()
+
+	for _, tenantID := range []string{"acme", "globex"} {
+		applied, err := manager.Provision(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Len(t, applied, 1)
+	}
+	assert.Equal(t, []string{"acme", "globex"}, strategy.Registry().Tenants())
+
+	err := manager.Run(ctx, "acme", func(ctx context.Context) error {
+		tenantID, _ := FromContext(ctx)
+		assert.Equal(t, "acme", tenantID)
+		_, err := repo.Create(ctx, &note{Text: "hello"})
+		return err
+	})
+	require.NoError(t, err)
+	count := func(tenantID string) (n int64) {
+		require.NoError(t, manager.Run(ctx, tenantID, func(ctx context.Context) (err error) {
+			n, err = repo.CountBySpec(ctx, repository.NewSpec())
+			return err
+		}))
+		return n
+	}
+	assert.Equal(t, int64(1), count("acme"))
+	assert.Equal(t, int64(0), count("globex"))
+
+	// Transactions run on the tenant database.
+	err = manager.Run(ctx, "globex", func(ctx context.Context) error {
+		return repository.NewTxManager(primary).WithinTx(ctx, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, &note{Text: "tx"})
+			return err
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count("globex"))
+
+	applied, err := manager.Migrate(ctx, "acme")
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	status, err := manager.Status(ctx, "acme")
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Applied)
+
+	require.NoError(t, manager.Deprovision(ctx, "acme"))
+	assert.Equal(t, []string{"globex"}, strategy.Registry().Tenants())
+
+	// Unknown tenants are rejected without opening a pool.
+	err = manager.Run(ctx, "initech", func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+	assert.Equal(t, []string{"globex"}, strategy.Registry().Tenants())
+
+	err = manager.Run(ctx, "../acme", func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+}
+
+func TestRegistryEvictsLeastRecentlyUsed(t *testing.T) {
+	_, strategy := newDatabaseManager(t, WithMaxPools(2))
+	registry := strategy.Registry()
+	ctx := context.Background()
+
+	first, err := registry.Get(ctx, "a")
+	require.NoError(t, err)
+	_, err = registry.Get(ctx, "b")
+	require.NoError(t, err)
+	again, err := registry.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	_, err = registry.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, registry.Tenants())
+}
+
+func TestRegistryKeepsAcquiredPoolsOpen(t *testing.T) {
+	_, strategy := newDatabaseManager(t, WithMaxPools(1))
+	registry := strategy.Registry()
+	ctx := context.Background()
+
+	acquired, release, err := registry.Acquire(ctx, "a")
+	require.NoError(t, err)
+	_, err = registry.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, registry.Tenants())
+
+	sqlDB, err := acquired.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Ping(), "an evicted pool stays open while acquired")
+	release()
+	release()
+	assert.Error(t, sqlDB.Ping(), "the evicted pool is closed once released")
+
+	acquired, release, err = registry.Acquire(ctx, "b")
+	require.NoError(t, err)
+	require.NoError(t, registry.Remove("b"))
+	sqlDB, err = acquired.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Ping())
+	release()
+	assert.Error(t, sqlDB.Ping())
+}
+
+func TestRegistryUse(t *testing.T) {
+	_, strategy := newDatabaseManager(t)
+	registry := strategy.Registry()
+	ctx := context.Background()
+
+	opened, err := registry.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, registry.Use(&Plugin{Strict: true}))
+	later, err := registry.Get(ctx, "b")
+	require.NoError(t, err)
+
+	for _, db := range []*gorm.DB{opened, later} {
+		assert.Contains(t, db.Config.Plugins, (&Plugin{}).Name())
+	}
+	require.NoError(t, registry.Use(&Plugin{}), "registering a plugin twice is ignored")
+}
+
+func TestSchemaStrategy(t *testing.T) {
+	conn := openTestDB(t, &Plugin{})
+	strategy := NewSchemaStrategy(conn, WithSchemaPrefix("t_"))
+
+	schema, err := strategy.Schema("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "t_acme", schema)
+	_, err = strategy.Schema(strings.Repeat("a", 62))
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+	_, err = strategy.Schema(`acme"; DROP SCHEMA public; --`)
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+
+	err = strategy.Bind(context.Background(), "acme", func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, db.ErrUnsupportedDriver)
+	assert.ErrorIs(t, strategy.Provision(context.Background(), "acme"), db.ErrUnsupportedDriver)
+}
+
+func TestDatabaseStrategyRejectsUncheckedTenants(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewRegistry(DSNOpener(db.DataSourceConfig{Driver: db.DriverSQLite, DSN: filepath.Join(dir, "tenant_"+TenantPlaceholder+".db"), LogLevel: "silent"}))
+	t.Cleanup(func() { _ = registry.Close() })
+	assert.Equal(t, DefaultMaxPools, registry.options.MaxPools)
+	strategy := NewDatabaseStrategy(registry)
+	ctx := context.Background()
+
+	bound := func(ctx context.Context) error { return nil }
+	assert.ErrorIs(t, strategy.Bind(ctx, "acme", bound), ErrTenantUnchecked)
+	assert.NoFileExists(t, filepath.Join(dir, "tenant_acme.db"))
+
+	// Provisioned tenants stay open and are bound without a check.
+	require.NoError(t, strategy.Provision(ctx, "acme"))
+	assert.NoError(t, strategy.Bind(ctx, "acme", bound))
+}
+
+func TestSharedStrategy(t *testing.T) {
+	conn := openTestDB(t, &Plugin{Strict: true})
+	strategy := NewSharedStrategy(conn, &invoice{})
+	manager := NewManager(conn, strategy)
+	repo := repository.NewCrudRepository[invoice, int64](conn)
+	ctx := context.Background()
+
+	for _, tenantID := range []string{"acme", "globex"} {
+		_, err := manager.Provision(ctx, tenantID)
+		require.NoError(t, err)
+		require.NoError(t, manager.Run(ctx, tenantID, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, &invoice{Total: 1})
+			return err
+		}))
+	}
+	require.NoError(t, manager.Deprovision(ctx, "acme"))
+
+	all, err := repo.ListAll(SkipTenant(ctx))
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "globex", all[0].TenantID)
+
+	assert.False(t, conn.Migrator().HasTable(DefaultTenantMigrationTable+"_acme"))
+
+	// Tenant migrations on the primary database are recorded apart from the
+	// application migrations, per tenant.
+	require.NoError(t, manager.Register("invoices", db.Migration{Version: 1, Name: "seed_invoice", Up: func(ctx context.Context, tx *gorm.DB) error {
+		return tx.WithContext(ctx).Create(&invoice{Total: 100}).Error
+	}}))
+	for _, tenantID := range []string{"globex", "initech"} {
+		applied, err := manager.Migrate(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Len(t, applied, 1, tenantID)
+		assert.True(t, conn.Migrator().HasTable(DefaultTenantMigrationTable+"_"+tenantID))
+	}
+	applied, err := manager.Migrate(ctx, "globex")
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.False(t, conn.Migrator().HasTable(db.DefaultMigrationTable))
+
+	for _, tenantID := range []string{"globex", "initech"} {
+		var seeded int64
+		require.NoError(t, conn.WithContext(SkipTenant(ctx)).Model(&invoice{}).Where("tenant_id = ? AND total = 100", tenantID).Count(&seeded).Error)
+		assert.Equal(t, int64(1), seeded, tenantID)
+	}
+}
+
+func TestManagerServeHTTP(t *testing.T) {
+	manager, _ := newDatabaseManager(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "provision", method: "POST", body: `{"tenant":"acme"}`, wantStatus: 200},
+		{name: "migrate", method: "PUT", body: `{"tenant":"acme"}`, wantStatus: 200, wantBody: `{"tenant":"acme"}`},
+		{name: "status", method: "GET", target: "?tenant=acme", wantStatus: 200},
+		{name: "deprovision", method: "DELETE", body: `{"tenant":"acme"}`, wantStatus: 200, wantBody: `{"tenant":"acme"}`},
+		{name: "missing tenant", method: "POST", body: `{}`, wantStatus: 400},
+		{name: "invalid tenant", method: "POST", body: `{"tenant":"a/b"}`, wantStatus: 400},
+		{name: "invalid body", method: "PUT", body: `{`, wantStatus: 400},
+		{name: "method not allowed", method: "PATCH", wantStatus: 405},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			manager.ServeHTTP(rec, httptest.NewRequest(tt.method, "/admin/tenants"+tt.target, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			if tt.wantStatus != 200 {
+				var body map[string]string
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.NotEmpty(t, body["error"])
+			}
+		})
+	}
+}
+
+func TestMiddlewareWithStrategy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager, strategy := newDatabaseManager(t)
+	_, err := manager.Provision(context.Background(), "acme")
+	require.NoError(t, err)
+
+	var conn *gorm.DB
+	engine := gin.New()
+	engine.Use(Middleware(WithStrategy(strategy), WithUnverifiedTenants()))
+	engine.GET("/", func(c *http.Context) {
+		conn, _ = repository.ConnFromContext(c.Request.Context())
+	})
+
+	serve := func(tenantID string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(http.TenantIDHeader, tenantID)
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, serve("acme"))
+	acme, err := strategy.Registry().Get(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Same(t, acme, conn)
+
+	assert.Equal(t, http.StatusBadRequest, serve("a.b"))
+	assert.Equal(t, http.StatusNotFound, serve("globex"))
+}
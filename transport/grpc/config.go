@@ -12,7 +12,7 @@ type Config struct {
 
 type GrpcServerConfig struct {
 	Enabled bool   `yaml:"enabled"`
-	Address string `yaml:"address"`
+	Address string `yaml:"address" validate:"required_if=Enabled true,omitempty,hostname_port"`
 }
 
 // GetConfig loads and returns the configuration
@@ -23,7 +23,7 @@ func GetConfig() (*Config, error) {
 	}
 	// Set default address if enabled and address is empty
 	if cfg.Grpc.Enabled && cfg.Grpc.Address == "" {
-		cfg.Grpc.Address = ":50051"
+		cfg.Grpc.Address = DefaultAddress
 	}
 	return &cfg, nil
 }
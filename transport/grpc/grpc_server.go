@@ -20,10 +20,23 @@ func NewGRPCServer(opts ...Option) GRPCServer {
 		o(&options)
 	}
 
+	// The request context interceptor runs first so the authentication and user
+	// interceptors see the scoped logger, and the user interceptor the principal.
+	var interceptors []grpc.UnaryServerInterceptor
+	if options.Logger != nil {
+		interceptors = append(interceptors, RequestContextInterceptor(options.Logger))
+	}
+	if options.AuthManager != nil {
+		interceptors = append(interceptors, AuthInterceptor(options.AuthManager))
+	}
+	if options.GRPCOptions.Interceptor != nil {
+		interceptors = append(interceptors, options.GRPCOptions.Interceptor)
+	}
+
 	return &grpcServer{
 		server: grpc.NewServer(
 			grpc.MaxConcurrentStreams(options.GRPCOptions.MaxConcurrentStreams),
-			grpc.UnaryInterceptor(options.GRPCOptions.Interceptor),
+			grpc.ChainUnaryInterceptor(interceptors...),
 		), // Create a new gRPC server
 		options: options,
 		address: options.Address,
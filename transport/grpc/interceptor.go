@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// RequestIDMetadataKey carries the request id; it is generated when absent and returned as a header.
+	RequestIDMetadataKey = "x-request-id"
+	// TenantIDMetadataKey carries the tenant id of the call.
+	TenantIDMetadataKey = "x-tenant-id"
+	// AuthorizationMetadataKey carries the bearer token of the call.
+	AuthorizationMetadataKey = "authorization"
+
+	bearerPrefix = "Bearer "
+)
+
+// RequestContextInterceptor returns a unary interceptor that derives a call-scoped
+// logger from l carrying the request and tenant ids found in the incoming
+// metadata, and stores it in the context where logger.FromContext finds it.
+// The user is added by AuthInterceptor, never from metadata the client could
+// forge.
+func RequestContextInterceptor(l logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		requestID := firstMetadata(md, RequestIDMetadataKey)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, requestID))
+
+		fields := []logger.Field{logger.RequestIDField(requestID), logger.String("method", info.FullMethod)}
+		if tenantID := firstMetadata(md, TenantIDMetadataKey); tenantID != "" {
+			fields = append(fields, logger.TenantIDField(tenantID))
+		}
+		return handler(logger.IntoContext(ctx, l.With(fields...)), req)
+	}
+}
+
+// AuthInterceptor returns a unary interceptor that authenticates the bearer
+// token of the AuthorizationMetadataKey metadata with manager, rejecting the
+// call with codes.Unauthenticated when it fails. The principal is stored in
+// the context, where auth.PrincipalFromContext finds it, and the user is added
+// to the call-scoped logger.
+func AuthInterceptor(manager auth.AuthManager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		token := firstMetadata(md, AuthorizationMetadataKey)
+		if len(token) < len(bearerPrefix) || !strings.EqualFold(token[:len(bearerPrefix)], bearerPrefix) {
+			logger.FromContext(ctx).Warn("Authorization metadata missing or without Bearer prefix")
+			return nil, status.Error(codes.Unauthenticated, "missing token")
+		}
+		token = strings.TrimSpace(token[len(bearerPrefix):])
+		if token == "" {
+			logger.FromContext(ctx).Warn("Empty token after Bearer prefix removal")
+			return nil, status.Error(codes.Unauthenticated, "missing token")
+		}
+
+		principal, err := manager.Authenticate(ctx, token)
+		if err != nil {
+			logger.FromContext(ctx).Error("Token authentication failed", logger.Error(err))
+			if errors.Is(err, auth.ErrTokenExpired) {
+				return nil, status.Error(codes.Unauthenticated, "token expired")
+			}
+			return nil, status.Error(codes.Unauthenticated, "invalid or malformed token")
+		}
+
+		ctx = auth.ContextWithPrincipal(ctx, principal)
+		ctx = logger.IntoContext(ctx, logger.FromContext(ctx).With(logger.UserField(principal.GetID())))
+		return handler(ctx, req)
+	}
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/ebrickdev/ebrick/security/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type principal struct{}
+
+func (principal) GetID() string                     { return "user-1" }
+func (principal) GetEmail() string                  { return "" }
+func (principal) GetRoles() []string                { return nil }
+func (principal) GetClaims() map[string]interface{} { return nil }
+
+type authManager struct{}
+
+func (authManager) Authenticate(ctx context.Context, token string) (auth.Principal, error) {
+	if token != "valid" {
+		return nil, errors.New("bad token")
+	}
+	return principal{}, nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	call := logger.NewMockLogger(ctrl)
+	user := logger.NewMockLogger(ctrl)
+	call.EXPECT().With(logger.UserField("user-1")).Return(user)
+	call.EXPECT().Warn(gomock.Any()).AnyTimes()
+	call.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	intercept := func(authorization string) (context.Context, error) {
+		ctx := logger.IntoContext(context.Background(), call)
+		if authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationMetadataKey, authorization))
+		}
+		var got context.Context
+		_, err := AuthInterceptor(authManager{})(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, func(ctx context.Context, req any) (any, error) {
+			got = ctx
+			return nil, nil
+		})
+		return got, err
+	}
+
+	ctx, err := intercept("bearer valid")
+	require.NoError(t, err)
+	p, ok := auth.PrincipalFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "user-1", p.GetID())
+	assert.Same(t, user, logger.FromContext(ctx))
+
+	for _, authorization := range []string{"", "Basic valid", "Bearer ", "Bearer forged"} {
+		_, err := intercept(authorization)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), authorization)
+	}
+}
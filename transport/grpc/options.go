@@ -1,6 +1,8 @@
 package grpc
 
 import (
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/ebrickdev/ebrick/security/auth"
 	"google.golang.org/grpc"
 )
 
@@ -8,6 +10,8 @@ type Options struct {
 	Address     string
 	Name        string
 	Version     string
+	Logger      logger.Logger    // When set, calls carry a request-scoped logger in their context
+	AuthManager auth.AuthManager // When set, calls must carry a bearer token it authenticates
 	GRPCOptions GRPCOptions
 }
 
@@ -65,3 +69,16 @@ func WithInterceptor(interceptor grpc.UnaryServerInterceptor) Option {
 		o.GRPCOptions.Interceptor = interceptor
 	}
 }
+
+func WithLogger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithAuthManager authenticates every call with AuthInterceptor.
+func WithAuthManager(manager auth.AuthManager) Option {
+	return func(o *Options) {
+		o.AuthManager = manager
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 var (
-	DefaultAddress = ":0"
+	DefaultAddress = ":50051"
 	DefaultName    = "go.ebrick.server"
 	DefaultVersion = "latest"
 )
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidETag is returned when an If-Match header is not a version ETag.
+var ErrInvalidETag = errors.New("invalid ETag")
+
+// ETag returns the strong entity tag of an entity version, e.g. "3".
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// SetETag sets the ETag response header to the entity version.
+func SetETag(c *Context, version int64) {
+	c.Header("ETag", ETag(version))
+}
+
+// IfMatchVersion returns the version in the request's If-Match header. ok is
+// false when the header is absent or "*".
+func IfMatchVersion(c *Context) (version int64, ok bool, err error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, false, ErrInvalidETag
+	}
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0, false, ErrInvalidETag
+	}
+	return version, true, nil
+}
+
+// ConcurrencyError is implemented by errors reporting a lost optimistic lock,
+// such as repository.ErrConcurrentModification.
+type ConcurrencyError interface {
+	error
+	ConcurrentModification() bool
+}
+
+// ConcurrencyStatus maps a ConcurrencyError to 412 Precondition Failed for
+// requests that sent If-Match and to 409 Conflict otherwise. It returns 0 for
+// other errors.
+func ConcurrencyStatus(c *Context, err error) int {
+	var ce ConcurrencyError
+	if !errors.As(err, &ce) || !ce.ConcurrentModification() {
+		return 0
+	}
+	if c.GetHeader("If-Match") != "" {
+		return StatusPreconditionFailed
+	}
+	return StatusConflict
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newETagContext(ifMatch string) *Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("PUT", "/", nil)
+	if ifMatch != "" {
+		c.Request.Header.Set("If-Match", ifMatch)
+	}
+	return c
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		ok      bool
+		err     error
+	}{
+		{"", 0, false, nil},
+		{"*", 0, false, nil},
+		{ETag(3), 3, true, nil},
+		{`W/"7"`, 7, true, nil},
+		{`"abc"`, 0, false, ErrInvalidETag},
+		{`12`, 0, false, ErrInvalidETag},
+	}
+	for _, tt := range tests {
+		version, ok, err := IfMatchVersion(newETagContext(tt.header))
+		assert.Equal(t, tt.version, version, tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		assert.Equal(t, tt.err, err, tt.header)
+	}
+}
+
+func TestConcurrencyStatus(t *testing.T) {
+	err := fmt.Errorf("update: %w", repository.ErrConcurrentModification)
+
+	assert.Equal(t, StatusPreconditionFailed, ConcurrencyStatus(newETagContext(ETag(1)), err))
+	assert.Equal(t, StatusConflict, ConcurrencyStatus(newETagContext(""), err))
+	assert.Zero(t, ConcurrencyStatus(newETagContext(""), fmt.Errorf("other")))
+}
+
+func TestSetETag(t *testing.T) {
+	c := newETagContext("")
+	SetETag(c, 4)
+	assert.Equal(t, `"4"`, c.Writer.Header().Get("ETag"))
+}
@@ -2,12 +2,16 @@ package middleware
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/ebrickdev/ebrick/transport/http"
 )
 
 const PagingParamsKey = "paging"
 
+// CursorParamsKey stores *CursorParams when PagingMiddleware runs in cursor mode.
+const CursorParamsKey = "cursor_paging"
+
 // PagingParams holds paging details.
 type PagingParams struct {
 	Page   int
@@ -15,12 +19,61 @@ type PagingParams struct {
 	Offset int
 }
 
+// CursorParams holds the cursor paging details of a request.
+type CursorParams struct {
+	Cursor string
+	Limit  int
+}
+
+// CursorLinks holds the URLs of the neighbouring pages; empty when there is none.
+type CursorLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// PagingOptions configures PagingMiddleware.
+type PagingOptions struct {
+	// Cursor switches from page/limit to cursor/limit parameters.
+	Cursor       bool
+	DefaultLimit int
+	// MaxLimit caps the requested limit; 0 means no cap.
+	MaxLimit int
+}
+
+type PagingOption func(*PagingOptions)
+
+// WithCursorPaging parses cursor/limit into CursorParams instead of page/limit.
+func WithCursorPaging() PagingOption {
+	return func(o *PagingOptions) {
+		o.Cursor = true
+	}
+}
+
+// WithDefaultLimit sets the limit used when the request has none.
+func WithDefaultLimit(limit int) PagingOption {
+	return func(o *PagingOptions) {
+		o.DefaultLimit = limit
+	}
+}
+
+// WithMaxLimit caps the limit a client may request.
+func WithMaxLimit(limit int) PagingOption {
+	return func(o *PagingOptions) {
+		o.MaxLimit = limit
+	}
+}
+
 // PagingMiddleware extracts paging query params and stores them in the context.
-func PagingMiddleware() http.HandlerFunc {
+func PagingMiddleware(opts ...PagingOption) http.HandlerFunc {
+	options := PagingOptions{DefaultLimit: 15}
+	for _, o := range opts {
+		o(&options)
+	}
+
 	return func(c *http.Context) {
 		// Default values
 		page := 1
-		limit := 15
+		limit := options.DefaultLimit
 
 		if pageStr := c.Query("page"); pageStr != "" {
 			if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
@@ -32,6 +85,19 @@ func PagingMiddleware() http.HandlerFunc {
 				limit = l
 			}
 		}
+		if options.MaxLimit > 0 && (limit == 0 || limit > options.MaxLimit) {
+			limit = options.MaxLimit
+		}
+
+		if options.Cursor {
+			c.Set(CursorParamsKey, &CursorParams{
+				Cursor: c.Query("cursor"),
+				Limit:  limit,
+			})
+			c.Next()
+			return
+		}
+
 		offset := (page - 1) * limit
 
 		// Set paging parameters in the context
@@ -44,3 +110,33 @@ func PagingMiddleware() http.HandlerFunc {
 		c.Next()
 	}
 }
+
+// SetCursorLinks builds the next and prev page URLs from the request URL and the
+// given cursors, emits them as a Link header and returns them for the response body.
+// Call it before writing the response.
+func SetCursorLinks(c *http.Context, next, prev string) CursorLinks {
+	links := CursorLinks{Next: cursorURL(c, next), Prev: cursorURL(c, prev)}
+	var header []string
+	if links.Next != "" {
+		header = append(header, "<"+links.Next+`>; rel="next"`)
+	}
+	if links.Prev != "" {
+		header = append(header, "<"+links.Prev+`>; rel="prev"`)
+	}
+	if len(header) > 0 {
+		c.Header("Link", strings.Join(header, ", "))
+	}
+	return links
+}
+
+// cursorURL returns the request URL with its cursor parameter replaced.
+func cursorURL(c *http.Context, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/transport/http"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(mw http.HandlerFunc, target string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/items", mw, handler)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, target, nil))
+	return rec
+}
+
+func TestPagingMiddlewareOffsetMode(t *testing.T) {
+	var params *PagingParams
+	serve(PagingMiddleware(), "/items?page=3&limit=10", func(c *http.Context) {
+		params = c.MustGet(PagingParamsKey).(*PagingParams)
+	})
+	assert.Equal(t, &PagingParams{Page: 3, Limit: 10, Offset: 20}, params)
+}
+
+func TestPagingMiddlewareCursorMode(t *testing.T) {
+	var params *CursorParams
+	serve(PagingMiddleware(WithCursorPaging(), WithMaxLimit(50)), "/items?cursor=abc&limit=500", func(c *http.Context) {
+		params = c.MustGet(CursorParamsKey).(*CursorParams)
+	})
+	assert.Equal(t, &CursorParams{Cursor: "abc", Limit: 50}, params)
+
+	serve(PagingMiddleware(WithCursorPaging(), WithDefaultLimit(20)), "/items", func(c *http.Context) {
+		params = c.MustGet(CursorParamsKey).(*CursorParams)
+	})
+	assert.Equal(t, &CursorParams{Limit: 20}, params)
+}
+
+func TestSetCursorLinks(t *testing.T) {
+	var links CursorLinks
+	rec := serve(PagingMiddleware(WithCursorPaging()), "/items?cursor=old&limit=5&q=x", func(c *http.Context) {
+		links = SetCursorLinks(c, "n1", "")
+		c.Status(nethttp.StatusOK)
+	})
+	assert.Equal(t, "/items?cursor=n1&limit=5&q=x", links.Next)
+	assert.Empty(t, links.Prev)
+	assert.Equal(t, `</items?cursor=n1&limit=5&q=x>; rel="next"`, rec.Header().Get("Link"))
+}
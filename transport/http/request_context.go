@@ -0,0 +1,34 @@
+package http
+
+import (
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader carries the request id; it is generated when absent and echoed in the response.
+	RequestIDHeader = "X-Request-ID"
+	// TenantIDHeader carries the tenant id of the request.
+	TenantIDHeader = "X-Tenant-ID"
+	// RequestIDKey is the gin context key of the request id.
+	RequestIDKey = "request_id"
+)
+
+// RequestContext returns a middleware that derives a request-scoped logger from l
+// carrying the request id, and stores it in the request context where
+// logger.FromContext finds it. The tenant is added by the tenancy middleware
+// once validated, never from the raw TenantIDHeader.
+func RequestContext(l logger.Logger) HandlerFunc {
+	return func(c *Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := logger.IntoContext(c.Request.Context(), l.With(logger.RequestIDField(requestID)))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ebrickdev/ebrick/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	root := logger.NewMockLogger(ctrl)
+	scoped := logger.NewMockLogger(ctrl)
+	// The unvalidated tenant header is left to the tenancy middleware.
+	root.EXPECT().With(logger.RequestIDField("req-1")).Return(scoped)
+
+	var got logger.Logger
+	engine := gin.New()
+	engine.Use(RequestContext(root))
+	engine.GET("/", func(c *Context) {
+		got = logger.FromContext(c.Request.Context())
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set(TenantIDHeader, "tenant-1")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	assert.Same(t, scoped, got)
+	assert.Equal(t, "req-1", rec.Header().Get(RequestIDHeader))
+}
+
+func TestRequestContextGeneratesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+
+	root := logger.NewMockLogger(ctrl)
+	root.EXPECT().With(gomock.Any()).Return(root)
+
+	engine := gin.New()
+	engine.Use(RequestContext(root))
+	engine.GET("/", func(c *Context) {})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	assert.Len(t, rec.Header().Get(RequestIDHeader), 36)
+}
@@ -1,6 +1,10 @@
 package http
 
-import "github.com/gin-gonic/gin"
+import (
+	nethttp "net/http"
+
+	"github.com/gin-gonic/gin"
+)
 
 type H map[string]any
 type Error = gin.Error
@@ -9,3 +13,8 @@ type Context = gin.Context
 type RouterGroup = gin.RouterGroup
 type Engine = gin.Engine
 type HandlerFunc = gin.HandlerFunc
+
+// WrapH wraps a standard library http.Handler as a HandlerFunc.
+func WrapH(h nethttp.Handler) HandlerFunc {
+	return gin.WrapH(h)
+}